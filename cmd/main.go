@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/your-repo/cyben-zen-tools/internal/commands"
-	"github.com/your-repo/cyben-zen-tools/internal/config"
 )
 
 // 版本信息变量
@@ -16,13 +15,7 @@ var (
 )
 
 func main() {
-	// 初始化配置
-	if err := config.Init(); err != nil {
-		fmt.Fprintf(os.Stderr, "配置初始化失败: %v\n", err)
-		os.Exit(1)
-	}
-
-	// 创建根命令
+	// 创建根命令（配置在解析命令行标志后初始化）
 	rootCmd := commands.NewRootCommand()
 	
 	// 设置版本信息
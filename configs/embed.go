@@ -0,0 +1,9 @@
+// Package configs 提供编译进程序的默认配置文件
+package configs
+
+import "embed"
+
+// FS 内置的默认配置文件（file-types.yaml、categories.yaml、commit-templates.yaml）
+//
+//go:embed *.yaml
+var FS embed.FS
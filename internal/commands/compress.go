@@ -1,19 +1,24 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"image"
+	imagecolor "image/color"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
 )
 
 // newCompressCommand 创建图片压缩命令
@@ -31,12 +36,15 @@ func newCompressCommand() *cobra.Command {
 支持的格式：
   - JPEG (.jpg, .jpeg): 质量优化 + 尺寸调整
   - PNG (.png): 无损压缩 + 尺寸调整  
-  - GIF (.gif): 尺寸调整
-  - 其他格式: 直接复制
+  - GIF (.gif): 尺寸调整，动图保留全部帧、延迟、处置方式和循环次数
+  - BMP (.bmp): 尺寸调整
+  - TIFF (.tif, .tiff): Deflate 无损压缩 + 尺寸调整
+  - WebP (.webp): 只能解码，默认输出为 PNG
+  - 无法解码的文件: 直接复制
 
 特性：
   - 自动添加时间戳避免覆盖
-  - 保持原文件扩展名
+  - 保持原文件扩展名（转换格式时改为新格式的扩展名）
   - 支持相对路径和绝对路径
   - 自动创建目标目录
 
@@ -47,16 +55,164 @@ func newCompressCommand() *cobra.Command {
   --src   源文件或文件夹路径（必需）
   --dist  目标路径（可选，默认当前目录）
   --rate  压缩比率 0.1-1.0（可选，默认0.8）
+  --width / --height          目标框（可只指定一边），指定后不再按 --rate 缩放
+  --max-width / --max-height  最大尺寸，等价于 --width / --height --fit inside
+  --fit    缩放到目标框的方式（默认 inside）
+          inside   保持比例缩放到框内
+          contain  保持比例缩放到框内，并留白补齐到框的尺寸（JPEG 为白色，其余透明）
+          cover    保持比例铺满整个框，居中裁掉超出部分
+          fill     拉伸到框的尺寸，不保持比例
+  --upscale 允许放大（默认只缩小不放大）
+  --target-size 单个文件的目标大小，例如 300KB、1.5MB（1KB = 1024 字节）
+          JPEG 二分搜索质量，仍超出时按 --min-scale 缩小尺寸；PNG、GIF 只缩小尺寸
+  --min-quality 目标大小模式下 JPEG 的最低质量（默认 40）
+  --min-scale   目标大小模式下尺寸最多缩小到的比例（默认 1，即不缩小）
+  --format 输出格式 jpeg、png、gif（默认与源文件相同），输出文件的扩展名随之改变
+  --background 转换为 JPEG 时透明区域的背景色（默认 #ffffff）
+  --min-savings 重新编码至少节省的百分比（默认 5），不足时（包括比原文件更大）保留原文件内容
+          仅在格式、尺寸不变且原文件满足 --target-size 时生效，strip、keep-safe 时比较只保留图像数据段的原文件
+  --metadata 元数据处理方式（默认 strip），图片总是先按 EXIF 方向旋转
+          strip      移除全部 EXIF、XMP、ICC、IPTC
+          keep       全部保留（仅 JPEG、PNG 输出），方向改为 1
+          keep-safe  只保留 ICC 色彩配置和 EXIF 版权，移除 GPS 位置等其他信息
+  --png-colors   量化为最多 N 色的调色板 PNG（PNG8，2-256，默认 0 不量化），保留透明度
+  --png-dither   PNG8 使用 Floyd–Steinberg 抖动（默认开启，--png-dither=false 关闭）
+  --png-min-psnr PNG8 的最低峰值信噪比（默认 30 dB），量化误差过大时保留真彩色
+  --gif-frame-step GIF 每 N 帧保留 1 帧（默认 1，保留全部帧），丢弃帧的延迟并入保留帧
+  --gif-colors     GIF 每个调色板最多的颜色数（默认 256），含透明色
+  --gif-palette    GIF 调色板（默认 original）
+          original  GIF 源文件沿用每帧的源调色板，只保留使用最多的颜色
+          adaptive  按缩放后的画面用中位切分重新生成全局调色板
+          其他格式转换为 GIF 时总是生成自适应调色板
+  --gif-dither     自适应调色板使用 Floyd–Steinberg 抖动（默认开启，--gif-dither=false 关闭）
+  -j, --jobs    压缩目录时并发处理的文件数（默认为 CPU 核数），输出按文件顺序显示
+  --max-memory  并发压缩时解码图片的内存预算（默认 1GB），按图片像素估算，超出时等待
+  --filter 缩放滤波器（可选，默认 catmull-rom）
+          nearest      最近邻，最快，缩小时锯齿明显
+          box          区域平均
+          bilinear     双线性
+          catmull-rom  三次卷积，清晰
+          lanczos3     Lanczos 3，最清晰，速度最慢
+          缩放在线性光中进行，避免缩小后画面偏暗
 
 示例:
   cyber-zen compress --src "images/" --dist "compressed/" --rate 0.7
-  cyber-zen compress --src "photo.jpg" --rate 0.5`,
+  cyber-zen compress --src "photo.jpg" --rate 0.5
+  cyber-zen compress --src "photo.jpg" --rate 0.5 --filter lanczos3
+  cyber-zen compress --src "photos/" --max-width 1920
+  cyber-zen compress --src "avatar.png" --width 256 --height 256 --fit cover
+  cyber-zen compress --src "photos/" --target-size 300KB --min-scale 0.5
+  cyber-zen compress --src "assets/" --jobs 8 --max-memory 4GB
+  cyber-zen compress --src "loading.gif" --rate 0.5 --gif-frame-step 2 --gif-colors 64
+  cyber-zen compress --src "demo.gif" --gif-palette adaptive --gif-colors 128 --gif-dither=false
+  cyber-zen compress --src "screenshots/" --png-colors 256
+  cyber-zen compress --src "icons/" --format jpeg --background "#f5f5f5"
+  cyber-zen compress --src "DCIM/" --max-width 1920 --metadata keep-safe`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			src, _ := cmd.Flags().GetString("src")
 			dist, _ := cmd.Flags().GetString("dist")
 			rate, _ := cmd.Flags().GetFloat64("rate")
-			
-			return runCompress(src, dist, rate)
+			filterName, _ := cmd.Flags().GetString("filter")
+
+			// 未显式指定时使用配置（含仓库 .cyber-zen.yaml）中的默认值
+			defaults := config.GetCompressConfig()
+			if !cmd.Flags().Changed("rate") && defaults.Rate > 0 {
+				rate = defaults.Rate
+			}
+			if !cmd.Flags().Changed("dist") && defaults.Dist != "" {
+				dist = defaults.Dist
+			}
+			if !cmd.Flags().Changed("filter") && defaults.Filter != "" {
+				filterName = defaults.Filter
+			}
+			targetSize, _ := cmd.Flags().GetString("target-size")
+			if !cmd.Flags().Changed("target-size") && defaults.TargetSize != "" {
+				targetSize = defaults.TargetSize
+			}
+			formatName, _ := cmd.Flags().GetString("format")
+			if !cmd.Flags().Changed("format") && defaults.Format != "" {
+				formatName = defaults.Format
+			}
+			backgroundText, _ := cmd.Flags().GetString("background")
+			if !cmd.Flags().Changed("background") && defaults.Background != "" {
+				backgroundText = defaults.Background
+			}
+			minSavings, _ := cmd.Flags().GetFloat64("min-savings")
+			if !cmd.Flags().Changed("min-savings") {
+				minSavings = defaults.MinSavings
+			}
+			metadataName, _ := cmd.Flags().GetString("metadata")
+			if !cmd.Flags().Changed("metadata") && defaults.Metadata != "" {
+				metadataName = defaults.Metadata
+			}
+
+			filter, err := parseResampleFilter(filterName)
+			if err != nil {
+				return err
+			}
+
+			width, _ := cmd.Flags().GetInt("width")
+			height, _ := cmd.Flags().GetInt("height")
+			maxWidth, _ := cmd.Flags().GetInt("max-width")
+			maxHeight, _ := cmd.Flags().GetInt("max-height")
+			fit, _ := cmd.Flags().GetString("fit")
+			upscale, _ := cmd.Flags().GetBool("upscale")
+			size, err := newSizeOptions(rate, cmd.Flags().Changed("rate"), width, height, maxWidth, maxHeight, fit, upscale)
+			if err != nil {
+				return err
+			}
+
+			minQuality, _ := cmd.Flags().GetInt("min-quality")
+			minScale, _ := cmd.Flags().GetFloat64("min-scale")
+			target, err := newTargetOptions(targetSize, minQuality, minScale)
+			if err != nil {
+				return err
+			}
+
+			format, err := parseOutputFormat(formatName)
+			if err != nil {
+				return err
+			}
+			background, err := parseBackground(backgroundText)
+			if err != nil {
+				return err
+			}
+			metadata, err := parseMetadataPolicy(metadataName)
+			if err != nil {
+				return err
+			}
+			if err := validateMinSavings(minSavings); err != nil {
+				return err
+			}
+
+			pngColors, _ := cmd.Flags().GetInt("png-colors")
+			pngDither, _ := cmd.Flags().GetBool("png-dither")
+			pngMinPSNR, _ := cmd.Flags().GetFloat64("png-min-psnr")
+			pngOpts, err := newPNGOptions(pngColors, pngDither, pngMinPSNR)
+			if err != nil {
+				return err
+			}
+
+			gifFrameStep, _ := cmd.Flags().GetInt("gif-frame-step")
+			gifColors, _ := cmd.Flags().GetInt("gif-colors")
+			gifPalette, _ := cmd.Flags().GetString("gif-palette")
+			gifDither, _ := cmd.Flags().GetBool("gif-dither")
+			gifOpts, err := newGIFOptions(gifFrameStep, gifColors, gifPalette, gifDither)
+			if err != nil {
+				return err
+			}
+
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			if jobs < 1 {
+				return fmt.Errorf("--jobs 必须大于 0")
+			}
+			maxMemoryText, _ := cmd.Flags().GetString("max-memory")
+			maxMemory, err := parseByteSize(maxMemoryText)
+			if err != nil {
+				return fmt.Errorf("--max-memory: %v", err)
+			}
+
+			return runCompress(src, dist, compressOptions{Size: size, Filter: filter, Target: target, GIF: gifOpts, PNG: pngOpts, Format: format, Background: background, Metadata: metadata, MinSavings: minSavings, Jobs: jobs, MaxMemory: maxMemory})
 		},
 	}
 
@@ -64,6 +220,29 @@ func newCompressCommand() *cobra.Command {
 	cmd.Flags().String("src", "", "源文件或文件夹路径")
 	cmd.Flags().String("dist", "", "目标路径（可选）")
 	cmd.Flags().Float64("rate", 0.8, "压缩比率 0.1-1.0（可选）")
+	cmd.Flags().String("filter", defaultResampleFilter, "缩放滤波器: nearest、box、bilinear、catmull-rom、lanczos3")
+	cmd.Flags().Int("width", 0, "目标宽度（保持宽高比）")
+	cmd.Flags().Int("height", 0, "目标高度（保持宽高比）")
+	cmd.Flags().Int("max-width", 0, "最大宽度，等价于 --width N --fit inside")
+	cmd.Flags().Int("max-height", 0, "最大高度，等价于 --height N --fit inside")
+	cmd.Flags().String("fit", "", "缩放到目标框的方式: contain、cover、fill、inside（默认 inside）")
+	cmd.Flags().Bool("upscale", false, "允许放大小于目标尺寸的图片")
+	cmd.Flags().String("target-size", "", "单个文件的目标大小，例如 300KB、1.5MB")
+	cmd.Flags().Int("min-quality", defaultMinQuality, "目标大小模式下 JPEG 的最低质量 1-100")
+	cmd.Flags().Float64("min-scale", 1, "目标大小模式下尺寸最多缩小到的比例，1 表示不缩小")
+	cmd.Flags().String("format", "", "输出格式: jpeg、png、gif（默认与源文件相同）")
+	cmd.Flags().String("background", defaultBackground, "转换为 JPEG 时透明区域的背景色，例如 #ffffff")
+	cmd.Flags().Float64("min-savings", defaultMinSavings, "重新编码至少节省的百分比，不足时保留原文件内容")
+	cmd.Flags().String("metadata", metadataStrip, "元数据处理方式: strip、keep、keep-safe（保留色彩配置和版权，移除 GPS 位置）")
+	cmd.Flags().Int("png-colors", 0, "PNG8 调色板的颜色数 2-256（含透明色），0 表示保留真彩色")
+	cmd.Flags().Bool("png-dither", true, "PNG8 量化时使用 Floyd–Steinberg 抖动")
+	cmd.Flags().Float64("png-min-psnr", defaultPNGMinPSNR, "PNG8 量化的最低峰值信噪比（dB），低于该值时保留真彩色，0 表示不检查")
+	cmd.Flags().Int("gif-frame-step", 1, "GIF 每 N 帧保留 1 帧，丢弃帧的延迟并入保留帧")
+	cmd.Flags().Int("gif-colors", maxGIFColors, "GIF 每个调色板最多的颜色数 2-256（含透明色）")
+	cmd.Flags().String("gif-palette", gifPaletteOriginal, "GIF 调色板: original（沿用源调色板）、adaptive（按画面重新生成）")
+	cmd.Flags().Bool("gif-dither", true, "GIF 自适应调色板使用 Floyd–Steinberg 抖动")
+	cmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "压缩目录时并发处理的文件数（默认为 CPU 核数）")
+	cmd.Flags().String("max-memory", defaultMaxMemory, "并发压缩时解码图片的内存预算，例如 512MB、2GB")
 	
 	// 标记必需参数
 	cmd.MarkFlagRequired("src")
@@ -71,15 +250,40 @@ func newCompressCommand() *cobra.Command {
 	return cmd
 }
 
+// compressOptions 图片压缩选项
+type compressOptions struct {
+	Size   sizeOptions    // 输出尺寸
+	Filter resampleFilter // 缩放使用的重采样滤波器
+	Target targetOptions  // 目标文件大小
+	GIF    gifOptions     // GIF 帧和调色板
+	PNG    pngOptions     // PNG8 量化
+
+	Format     string           // 输出格式，为空时与源文件相同
+	Background imagecolor.Color // 转换为 JPEG 时透明区域的背景色
+	Metadata   string           // 元数据处理方式：strip、keep、keep-safe
+	MinSavings float64          // 重新编码至少节省的百分比，不足时保留原文件内容
+
+	Jobs      int   // 压缩目录时并发处理的文件数
+	MaxMemory int64 // 并发处理时解码图片的内存预算（字节）
+}
+
 // runCompress 执行图片压缩
-func runCompress(src, dist string, rate float64) error {
+func runCompress(src, dist string, opts compressOptions) error {
 	color.Green("开始压缩图片...")
 	color.Cyan("源路径: %s", src)
 	color.Cyan("目标路径: %s", dist)
-	color.Cyan("压缩比率: %.2f", rate)
+	color.Cyan("输出尺寸: %s", opts.Size.describe())
+	color.Cyan("缩放滤波器: %s", opts.Filter.Name)
+	color.Cyan("GIF: %s", opts.GIF.describe())
+	if opts.Format != "" {
+		color.Cyan("输出格式: %s", opts.Format)
+	}
+	if opts.Target.Size > 0 {
+		color.Cyan("目标大小: %s（JPEG 最低质量 %d，尺寸最多缩小到 %.2f）", formatByteSize(opts.Target.Size), opts.Target.MinQuality, opts.Target.MinScale)
+	}
 
 	// 验证压缩比率
-	if rate < 0.1 || rate > 1.0 {
+	if opts.Size.Rate < 0.1 || opts.Size.Rate > 1.0 {
 		return fmt.Errorf("压缩比率必须在 0.1 到 1.0 之间")
 	}
 
@@ -125,7 +329,7 @@ func runCompress(src, dist string, rate float64) error {
 
 	if fileInfo.IsDir() {
 		// 压缩目录
-		return compressDirectory(srcAbs, distWithTimestamp, rate)
+		return compressDirectory(srcAbs, distWithTimestamp, opts)
 	} else {
 		// 压缩单个文件：确保目标文件名包含原文件扩展名
 		originalExt := filepath.Ext(srcAbs)
@@ -143,7 +347,7 @@ func runCompress(src, dist string, rate float64) error {
 			return fmt.Errorf("创建目标目录失败: %v", err)
 		}
 		
-		return compressFile(srcAbs, distWithTimestamp, rate)
+		return compressFile(srcAbs, distWithTimestamp, opts)
 	}
 }
 
@@ -170,7 +374,7 @@ func containsTimestamp(path string) bool {
 }
 
 // compressDirectory 压缩目录中的所有图片
-func compressDirectory(srcDir, distDir string, rate float64) error {
+func compressDirectory(srcDir, distDir string, opts compressOptions) error {
 	color.Yellow("压缩目录: %s", srcDir)
 	
 	// 创建目标目录
@@ -178,7 +382,9 @@ func compressDirectory(srcDir, distDir string, rate float64) error {
 		return fmt.Errorf("创建目标目录失败: %v", err)
 	}
 
-	// 遍历源目录
+	// 遍历源目录，收集需要压缩的图片
+	var jobs []compressJob
+	distPaths := map[string]bool{}
 	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -209,13 +415,15 @@ func compressDirectory(srcDir, distDir string, rate float64) error {
 			return err
 		}
 
-		// 压缩文件（保持原扩展名）
-		color.Cyan("压缩: %s", relPath)
-		if err := compressImageFile(path, distPath, rate); err != nil {
-			color.Red("压缩失败: %s - %v", relPath, err)
-			return nil // 继续处理其他文件
+		// 加入压缩队列：扩展名与输出格式一致，转换后重名时在文件名后加上原扩展名
+		ext := filepath.Ext(distPath)
+		distPath = rewriteExtension(distPath, outputFormat(extensionFormats[strings.ToLower(ext)], opts.Format))
+		if distPaths[distPath] {
+			distExt := filepath.Ext(distPath)
+			distPath = strings.TrimSuffix(distPath, distExt) + "_" + strings.ToLower(strings.TrimPrefix(ext, ".")) + distExt
 		}
-
+		distPaths[distPath] = true
+		jobs = append(jobs, compressJob{Src: path, Dist: distPath, Rel: relPath})
 		return nil
 	})
 
@@ -223,12 +431,17 @@ func compressDirectory(srcDir, distDir string, rate float64) error {
 		return fmt.Errorf("遍历目录失败: %v", err)
 	}
 
+	// 并发压缩，单个文件失败时继续处理其他文件
+	color.Cyan("共 %d 个图片，%s", len(jobs), describeJobs(opts))
+	summary := runCompressJobs(jobs, opts, color.Output)
+	summary.print()
+
 	color.Green("✓ 目录压缩完成: %s", distDir)
 	return nil
 }
 
 // compressFile 压缩单个文件
-func compressFile(srcFile, distFile string, rate float64) error {
+func compressFile(srcFile, distFile string, opts compressOptions) error {
 	color.Yellow("压缩文件: %s", filepath.Base(srcFile))
 	
 	// 检查是否为图片文件
@@ -237,117 +450,172 @@ func compressFile(srcFile, distFile string, rate float64) error {
 	}
 
 	// 压缩文件
-	if err := compressImageFile(srcFile, distFile, rate); err != nil {
+	result, err := compressImageFile(srcFile, distFile, opts, compressLog{w: color.Output})
+	if err != nil {
 		return fmt.Errorf("压缩文件失败: %v", err)
 	}
 
-	color.Green("✓ 文件压缩完成: %s", distFile)
+	color.Green("✓ 文件压缩完成: %s", result.Path)
 	return nil
 }
 
 // isImageFile 检查是否为图片文件
 func isImageFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
-	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp"}
-	
-	for _, imgExt := range imageExts {
-		if ext == imgExt {
-			return true
-		}
-	}
-	return false
+	_, ok := extensionFormats[ext]
+	return ok
 }
 
 // compressImageFile 压缩单个图片文件
-func compressImageFile(srcFile, distFile string, rate float64) error {
+//
+// 输出写入 log，并发压缩时每个文件的输出不会交错
+func compressImageFile(srcFile, distFile string, opts compressOptions, log compressLog) (compressResult, error) {
 	// 读取源图片
 	srcData, err := os.ReadFile(srcFile)
 	if err != nil {
-		return fmt.Errorf("读取源文件失败: %v", err)
+		return compressResult{}, fmt.Errorf("读取源文件失败: %v", err)
 	}
 
 	// 解码图片
 	img, format, err := decodeImage(srcData)
 	if err != nil {
 		// 解码失败，直接复制文件并保持原扩展名
-		color.Yellow("⚠️  无法解码图片: %s，直接复制文件", filepath.Base(srcFile))
+		log.yellow("⚠️  无法解码图片: %s，直接复制文件", filepath.Base(srcFile))
 		if err := os.WriteFile(distFile, srcData, 0644); err != nil {
-			return fmt.Errorf("复制文件失败: %v", err)
+			return compressResult{}, fmt.Errorf("复制文件失败: %v", err)
 		}
 		
 		// 显示复制信息
-		color.Green("✓ 文件复制完成: %s", filepath.Base(srcFile))
-		color.Cyan("  文件大小: %d bytes", len(srcData))
-		return nil
+		log.green("✓ 文件复制完成: %s", filepath.Base(srcFile))
+		log.cyan("  文件大小: %d bytes", len(srcData))
+		size := int64(len(srcData))
+		return compressResult{Path: distFile, OriginalSize: size, CompressedSize: size}, nil
 	}
 
+	// 按 EXIF 方向旋转后再计算尺寸
+	meta := readMetadata(srcData, format)
+	img = orientImage(img, meta.Orientation)
+
 	// 获取原始尺寸
 	originalBounds := img.Bounds()
 	originalWidth := originalBounds.Dx()
 	originalHeight := originalBounds.Dy()
 
-	// 计算新尺寸（按比率缩小）
-	newWidth := int(float64(originalWidth) * rate)
-	newHeight := int(float64(originalHeight) * rate)
+	// 计算新尺寸（保持宽高比，默认不放大）
+	plan := planResize(originalWidth, originalHeight, opts.Size)
+	newSize := plan.Size()
 
-	// 如果新尺寸太小，保持最小尺寸
-	if newWidth < 50 {
-		newWidth = 50
+	// 确定输出格式，与源文件不同时改写扩展名
+	distFormat := outputFormat(format, opts.Format)
+	distFile = rewriteExtension(distFile, distFormat)
+	if anim, ok := img.(*gifAnimation); ok && distFormat != "gif" && len(anim.GIF.Image) > 1 {
+		log.yellow("⚠️  %s 不支持动画，只保留第一帧: %s", distFormat, filepath.Base(srcFile))
 	}
-	if newHeight < 50 {
-		newHeight = 50
+
+	// 压缩时先编码到内存，成功后再写入目标文件
+	kept := meta.apply(opts.Metadata)
+	var output bytes.Buffer
+	var target *targetResult
+	if opts.Target.Size > 0 {
+		// 目标大小模式：搜索满足大小的质量和尺寸，预留写入元数据的空间
+		targetOpts := opts
+		if len(kept.names()) > 0 {
+			targetOpts.Target.Size = max(opts.Target.Size-metadataSize(kept), 1)
+		}
+		var result targetResult
+		if result, err = compressToTarget(img, distFormat, plan, targetOpts); err == nil {
+			output.Write(result.Data)
+			newSize = plan.scaled(result.Scale).Size()
+			target = &result
+		}
+	} else {
+		err = imageEncoders[distFormat](img, &output, plan, opts)
 	}
 
-	// 创建目标文件
-	distFileHandle, err := os.Create(distFile)
 	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %v", err)
-	}
-	defer distFileHandle.Close()
-
-	// 根据格式进行压缩
-	switch format {
-	case "jpeg", "jpg":
-		// JPEG 压缩：先优化质量，再调整尺寸
-		err = compressJPEG(img, distFileHandle, newWidth, newHeight, rate)
-	case "png":
-		// PNG 压缩：先优化质量，再调整尺寸
-		err = compressPNG(img, distFileHandle, newWidth, newHeight, rate)
-	case "gif":
-		// GIF 压缩：先优化质量，再调整尺寸
-		err = compressGIF(img, distFileHandle, newWidth, newHeight, rate)
-	default:
-		// 不支持的格式，直接复制
-		color.Yellow("⚠️  不支持的格式: %s，直接复制文件", format)
-		_, err = distFileHandle.Write(srcData)
+		return compressResult{}, fmt.Errorf("压缩图片失败: %v", err)
 	}
 
-	if err != nil {
-		return fmt.Errorf("压缩图片失败: %v", err)
+	// 写入保留的元数据
+	data, written := embedMetadata(output.Bytes(), distFormat, kept)
+	originalSize := int64(len(srcData))
+	compressedSize := int64(len(data))
+
+	// 重新编码节省不足 --min-savings 时保留原文件内容（strip、keep-safe 时只保留原文件的图像数据段）
+	reused, blocker := reusableOriginal(srcData, format, distFormat, image.Pt(originalWidth, originalHeight), newSize, meta, kept, opts)
+	if saving := savingsPercent(int64(len(reused)), compressedSize); reused != nil && saving < opts.MinSavings {
+		if err := os.WriteFile(distFile, reused, 0644); err != nil {
+			return compressResult{}, fmt.Errorf("写入目标文件失败: %v", err)
+		}
+
+		reusedSize := int64(len(reused))
+		log.green("✓ 保留原文件: %s", filepath.Base(srcFile))
+		log.cyan("  尺寸: %dx%d", originalWidth, originalHeight)
+		if len(meta.names()) > 0 {
+			log.cyan("  元数据: %s", meta.describe(opts.Metadata, written))
+		}
+		if reusedSize < originalSize {
+			log.cyan("  移除非图像数据: %d bytes", originalSize-reusedSize)
+		}
+		log.cyan("  重新编码: %d bytes，节省 %.2f%%，低于 %.1f%%", compressedSize, saving, opts.MinSavings)
+		log.cyan("  文件大小: %d bytes", reusedSize)
+		return compressResult{Path: distFile, OriginalSize: originalSize, CompressedSize: reusedSize, KeptOriginal: true, EncodedSize: compressedSize}, nil
 	}
 
-	// 获取压缩后的文件大小
-	distInfo, err := distFileHandle.Stat()
-	if err != nil {
-		return fmt.Errorf("获取目标文件信息失败: %v", err)
+	if len(written) < len(kept.names()) {
+		log.yellow("⚠️  %s 不支持写入元数据，已移除: %s", distFormat, filepath.Base(srcFile))
+	}
+
+	// 写入目标文件
+	if err := os.WriteFile(distFile, data, 0644); err != nil {
+		return compressResult{}, fmt.Errorf("写入目标文件失败: %v", err)
 	}
 
-	originalSize := len(srcData)
-	compressedSize := distInfo.Size()
 	compressionRatio := float64(compressedSize) / float64(originalSize)
 
-	color.Green("✓ 压缩完成: %s", filepath.Base(srcFile))
-	color.Cyan("  原始尺寸: %dx%d", originalWidth, originalHeight)
-	color.Cyan("  压缩尺寸: %dx%d", newWidth, newHeight)
-	color.Cyan("  原始大小: %d bytes", originalSize)
-	color.Cyan("  压缩大小: %d bytes", compressedSize)
-	color.Cyan("  压缩比率: %.2f%%", compressionRatio*100)
+	log.green("✓ 压缩完成: %s", filepath.Base(srcFile))
+	log.cyan("  原始尺寸: %dx%d", originalWidth, originalHeight)
+	log.cyan("  压缩尺寸: %dx%d", newSize.X, newSize.Y)
+	if distFormat != format {
+		log.cyan("  转换格式: %s → %s", format, distFormat)
+	}
+	if distFormat == "png" && opts.PNG.Colors > 0 {
+		if pngQuantized(data) {
+			log.cyan("  PNG 模式: %s", opts.PNG.describe())
+		} else {
+			log.yellow("  ⚠️  量化后峰值信噪比低于 %.1f dB，保留真彩色", opts.PNG.MinPSNR)
+		}
+	}
+	if name, ok := orientationNames[meta.Orientation]; ok {
+		log.cyan("  方向: 按 EXIF %s", name)
+	}
+	if len(meta.names()) > 0 {
+		log.cyan("  元数据: %s", meta.describe(opts.Metadata, written))
+	}
+	log.cyan("  原始大小: %d bytes", originalSize)
+	log.cyan("  压缩大小: %d bytes", compressedSize)
+	log.cyan("  压缩比率: %.2f%%", compressionRatio*100)
+	if compressedSize > originalSize && blocker != "" {
+		log.yellow("  ⚠️  输出比原文件大 %d bytes，未保留原文件: %s", compressedSize-originalSize, blocker)
+	}
+	if target != nil {
+		log.cyan("  目标大小: %s，%s", formatByteSize(opts.Target.Size), target.describe())
+	}
 
-	return nil
+	return compressResult{Path: distFile, OriginalSize: originalSize, CompressedSize: compressedSize}, nil
 }
 
 // decodeImage 解码图片数据
 func decodeImage(data []byte) (image.Image, string, error) {
+	// image.Decode 只保留 GIF 的第一帧
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		anim, err := decodeGIFAnimation(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("无法解码 GIF: %v", err)
+		}
+		return anim, "gif", nil
+	}
+
 	img, format, err := image.Decode(strings.NewReader(string(data)))
 	if err != nil {
 		return nil, "", fmt.Errorf("无法解码图片格式: %v", err)
@@ -356,8 +624,16 @@ func decodeImage(data []byte) (image.Image, string, error) {
 }
 
 // compressJPEG 压缩JPEG图片
-func compressJPEG(img image.Image, file *os.File, width, height int, rate float64) error {
-	// 计算JPEG质量（基于压缩比率，但优先保证质量）
+func compressJPEG(img image.Image, w io.Writer, plan resizePlan, opts compressOptions) error {
+	// 调整图片尺寸（JPEG 没有透明通道，留白和透明区域使用背景色）
+	resizedImg := flattenAlpha(applyResizePlan(img, plan, opts.Filter, opts.Background), opts.Background)
+
+	// 编码为JPEG
+	return jpeg.Encode(w, resizedImg, &jpeg.Options{Quality: jpegQuality(opts.Size.Rate)})
+}
+
+// jpegQuality 计算JPEG质量（基于压缩比率，但优先保证质量）
+func jpegQuality(rate float64) int {
 	quality := int(85 + (rate-0.5)*30) // 质量范围：70-100
 	if quality < 70 {
 		quality = 70
@@ -365,73 +641,34 @@ func compressJPEG(img image.Image, file *os.File, width, height int, rate float6
 	if quality > 100 {
 		quality = 100
 	}
-
-	// 调整图片尺寸
-	resizedImg := resizeImage(img, width, height)
-
-	// 编码为JPEG
-	return jpeg.Encode(file, resizedImg, &jpeg.Options{Quality: quality})
+	return quality
 }
 
 // compressPNG 压缩PNG图片
-func compressPNG(img image.Image, file *os.File, width, height int, rate float64) error {
+func compressPNG(img image.Image, w io.Writer, plan resizePlan, opts compressOptions) error {
 	// 调整图片尺寸
-	resizedImg := resizeImage(img, width, height)
+	resizedImg := applyResizePlan(img, plan, opts.Filter, transparentBackground)
+
+	// 指定 --png-colors 时量化为调色板 PNG
+	if opts.PNG.Colors > 0 {
+		return compressPNG8(resizedImg, w, opts.PNG)
+	}
 
 	// PNG使用默认压缩（PNG是无损格式，主要通过尺寸调整来减小文件大小）
-	return png.Encode(file, resizedImg)
+	return png.Encode(w, resizedImg)
 }
 
 // compressGIF 压缩GIF图片
-func compressGIF(img image.Image, file *os.File, width, height int, rate float64) error {
-	// 调整图片尺寸
-	resizedImg := resizeImage(img, width, height)
-
-	// GIF使用默认编码（GIF压缩主要通过尺寸调整）
-	return gif.Encode(file, resizedImg, nil)
-}
-
-// resizeImage 调整图片尺寸（简化实现）
-func resizeImage(img image.Image, width, height int) image.Image {
-	// 这里是一个简化的实现
-	// 在实际项目中，你可能需要使用专门的图片处理库来进行高质量的缩放
-	
-	// 获取原始尺寸
-	bounds := img.Bounds()
-	originalWidth := bounds.Dx()
-	originalHeight := bounds.Dy()
-
-	// 如果尺寸相同，直接返回原图
-	if originalWidth == width && originalHeight == height {
-		return img
+func compressGIF(img image.Image, w io.Writer, plan resizePlan, opts compressOptions) error {
+	// GIF 源文件缩放全部帧，保留延迟、处置方式和循环次数
+	if anim, ok := img.(*gifAnimation); ok {
+		return gif.EncodeAll(w, compressGIFAnimation(anim.GIF, plan, opts))
 	}
 
-	// 简化的缩放实现：取平均值
-	// 注意：这是一个非常简化的实现，实际应该使用双线性插值等算法
-	resized := image.NewRGBA(image.Rect(0, 0, width, height))
-	
-	// 计算缩放比例
-	scaleX := float64(originalWidth) / float64(width)
-	scaleY := float64(originalHeight) / float64(height)
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			// 计算原图对应位置
-			srcX := int(float64(x) * scaleX)
-			srcY := int(float64(y) * scaleY)
-			
-			// 确保不越界
-			if srcX >= originalWidth {
-				srcX = originalWidth - 1
-			}
-			if srcY >= originalHeight {
-				srcY = originalHeight - 1
-			}
+	// 调整图片尺寸，半透明像素按 alpha 转换为透明或不透明
+	resizedImg := gifOpaque(applyResizePlan(img, plan, opts.Filter, transparentBackground))
 
-			// 复制像素
-			resized.Set(x, y, img.At(srcX, srcY))
-		}
-	}
-
-	return resized
-} 
\ No newline at end of file
+	// 其他格式转换为 GIF 时按画面生成自适应调色板（gif.Encode 默认使用固定的 Plan9 调色板）
+	palette := medianCutQuantizer{}.Quantize(make(imagecolor.Palette, 0, opts.GIF.Colors), resizedImg)
+	return gif.Encode(w, quantizeImage(resizedImg, palette, opts.GIF.Dither), nil)
+}
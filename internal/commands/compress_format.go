@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+
+	// 注册 WebP 解码器（没有编码器，WebP 默认输出为 PNG）
+	_ "golang.org/x/image/webp"
+)
+
+// defaultBackground 转换为 JPEG 时透明区域的默认背景色
+const defaultBackground = "#ffffff"
+
+// imageEncoders 各输出格式的编码函数
+var imageEncoders = map[string]func(image.Image, io.Writer, resizePlan, compressOptions) error{
+	"jpeg": compressJPEG,
+	"png":  compressPNG,
+	"gif":  compressGIF,
+	"bmp":  compressBMP,
+	"tiff": compressTIFF,
+}
+
+// convertFormats --format 可选的输出格式
+var convertFormats = []string{"jpeg", "png", "gif"}
+
+// formatExtensions 输出格式对应的扩展名
+var formatExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+	"bmp":  ".bmp",
+	"tiff": ".tif",
+}
+
+// extensionFormats 扩展名对应的图片格式
+var extensionFormats = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+	".gif":  "gif",
+	".bmp":  "bmp",
+	".tif":  "tiff",
+	".tiff": "tiff",
+	".webp": "webp",
+}
+
+// parseOutputFormat 解析 --format，为空表示与源文件相同
+func parseOutputFormat(name string) (string, error) {
+	name = strings.ToLower(name)
+	if name == "jpg" {
+		name = "jpeg"
+	}
+	if name == "" {
+		return "", nil
+	}
+	for _, format := range convertFormats {
+		if name == format {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的输出格式: %s（可选 %s）", name, strings.Join(convertFormats, "、"))
+}
+
+// outputFormat 确定输出格式：优先使用 --format，否则与源文件相同，无法编码的格式（WebP）输出为 PNG
+func outputFormat(source, format string) string {
+	if format != "" {
+		return format
+	}
+	if _, ok := imageEncoders[source]; !ok {
+		return "png"
+	}
+	return source
+}
+
+// rewriteExtension 将路径的扩展名改为与输出格式一致，已一致时（如 .jpeg）保持不变
+func rewriteExtension(path, format string) string {
+	ext := filepath.Ext(path)
+	if extensionFormats[strings.ToLower(ext)] == format {
+		return path
+	}
+	return strings.TrimSuffix(path, ext) + formatExtensions[format]
+}
+
+// parseBackground 解析背景色，支持 #rrggbb 和 #rgb，# 可省略
+func parseBackground(text string) (color.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return nil, fmt.Errorf("无效的背景色: %s（例如 #ffffff）", text)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+// flattenAlpha 将半透明图片合成到背景色上，用于不支持透明的格式
+func flattenAlpha(img image.Image, background color.Color) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, img, bounds.Min, draw.Over)
+	return flat
+}
+
+// compressBMP 压缩BMP图片（BMP 不压缩像素，主要通过尺寸调整减小文件大小）
+func compressBMP(img image.Image, w io.Writer, plan resizePlan, opts compressOptions) error {
+	return bmp.Encode(w, applyResizePlan(img, plan, opts.Filter, transparentBackground))
+}
+
+// compressTIFF 压缩TIFF图片，使用 Deflate 无损压缩
+func compressTIFF(img image.Image, w io.Writer, plan resizePlan, opts compressOptions) error {
+	resizedImg := applyResizePlan(img, plan, opts.Filter, transparentBackground)
+	return tiff.Encode(w, resizedImg, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+func TestParseOutputFormatAndBackground(t *testing.T) {
+	for name, want := range map[string]string{"": "", "JPG": "jpeg", "png": "png", "gif": "gif"} {
+		if got, err := parseOutputFormat(name); err != nil || got != want {
+			t.Errorf("%q: 期望 %q，实际为 %q, %v", name, want, got, err)
+		}
+	}
+	if _, err := parseOutputFormat("webp"); err == nil {
+		t.Error("不支持的输出格式应报错")
+	}
+
+	for text, want := range map[string]color.RGBA{
+		"#ffffff": {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		"f5f5f5":  {R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff},
+		"#0a0":    {G: 0xaa, A: 0xff},
+	} {
+		if got, err := parseBackground(text); err != nil || got != want {
+			t.Errorf("%q: 期望 %v，实际为 %v, %v", text, want, got, err)
+		}
+	}
+	for _, text := range []string{"", "white", "#12345", "#gggggg"} {
+		if _, err := parseBackground(text); err == nil {
+			t.Errorf("%q 应报错", text)
+		}
+	}
+}
+
+func TestOutputFormatAndExtension(t *testing.T) {
+	if outputFormat("webp", "") != "png" || outputFormat("bmp", "") != "bmp" || outputFormat("png", "jpeg") != "jpeg" {
+		t.Error("输出格式不正确")
+	}
+	for path, want := range map[string]string{
+		"a/photo.jpeg": "a/photo.jpeg",
+		"a/photo.PNG":  "a/photo.jpg",
+		"a/logo.webp":  "a/logo.jpg",
+	} {
+		if got := rewriteExtension(path, "jpeg"); got != want {
+			t.Errorf("%s: 期望 %s，实际为 %s", path, want, got)
+		}
+	}
+}
+
+func TestFlattenAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 0xff, A: 0})
+
+	flat := flattenAlpha(img, color.RGBA{G: 0xff, A: 0xff})
+	if got := color.RGBAModel.Convert(flat.At(0, 0)); got != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("不透明像素应保持不变，实际为 %v", got)
+	}
+	if got := color.RGBAModel.Convert(flat.At(1, 0)); got != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("透明像素应为背景色，实际为 %v", got)
+	}
+
+	opaque := image.NewYCbCr(image.Rect(0, 0, 2, 2), image.YCbCrSubsampleRatio444)
+	if flattenAlpha(opaque, color.White) != image.Image(opaque) {
+		t.Error("不透明图片应直接返回")
+	}
+}
+
+func TestCompressDirectoryConvertsFormats(t *testing.T) {
+	srcDir, distDir := t.TempDir(), t.TempDir()
+	write := func(name string, encode func(*os.File) error) {
+		file, err := os.Create(filepath.Join(srcDir, name))
+		if err != nil {
+			t.Fatalf("创建图片失败: %v", err)
+		}
+		defer file.Close()
+		if err := encode(file); err != nil {
+			t.Fatalf("编码 %s 失败: %v", name, err)
+		}
+	}
+
+	// 左半透明的 PNG，与 logo.bmp 转换后重名
+	transparent := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 10; x < 20; x++ {
+			transparent.SetNRGBA(x, y, color.NRGBA{B: 0xff, A: 0xff})
+		}
+	}
+	write("logo.png", func(f *os.File) error { return png.Encode(f, transparent) })
+	write("logo.bmp", func(f *os.File) error { return bmp.Encode(f, randomNRGBA(20, 20, true)) })
+	write("scan.tiff", func(f *os.File) error { return tiff.Encode(f, randomNRGBA(20, 20, true), nil) })
+	webp, err := os.ReadFile(filepath.Join("testdata", "gopher.webp"))
+	if err != nil {
+		t.Fatalf("读取测试图片失败: %v", err)
+	}
+	write("gopher.webp", func(f *os.File) error { _, err := f.Write(webp); return err })
+
+	background, _ := parseBackground("#00ff00")
+	opts := compressOptions{
+		Size:       sizeOptions{Rate: 1},
+		Filter:     resampleFilters[defaultResampleFilter],
+		Format:     "jpeg",
+		Background: background,
+		Jobs:       2,
+		MaxMemory:  1 << 20,
+	}
+	if err := compressDirectory(srcDir, distDir, opts); err != nil {
+		t.Fatalf("压缩目录失败: %v", err)
+	}
+
+	for _, name := range []string{"logo.jpg", "logo_png.jpg", "scan.jpg", "gopher.jpg"} {
+		data, err := os.ReadFile(filepath.Join(distDir, name))
+		if err != nil {
+			t.Errorf("缺少输出文件 %s: %v", name, err)
+			continue
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s 不是 JPEG: %v", name, err)
+			continue
+		}
+		if name == "logo_png.jpg" {
+			// 透明区域合成到背景色上
+			if r, g, b, _ := img.At(2, 10).RGBA(); r>>8 > 16 || g>>8 < 240 || b>>8 > 16 {
+				t.Errorf("透明区域应为绿色背景，实际为 %d,%d,%d", r>>8, g>>8, b>>8)
+			}
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"sort"
+	"strings"
+)
+
+// maxGIFColors GIF 调色板最多的颜色数
+const maxGIFColors = 256
+
+// GIF 调色板模式
+const (
+	gifPaletteOriginal = "original" // GIF 源文件沿用每帧的源调色板
+	gifPaletteAdaptive = "adaptive" // 按缩放后的画面重新生成调色板
+)
+
+// gifPaletteModes --gif-palette 可选的模式
+var gifPaletteModes = []string{gifPaletteOriginal, gifPaletteAdaptive}
+
+// gifOptions GIF 压缩选项
+type gifOptions struct {
+	FrameStep int    // 每 FrameStep 帧保留 1 帧，1 表示保留全部帧
+	Colors    int    // 每个调色板最多的颜色数（含透明色）
+	Palette   string // 调色板模式，非 GIF 源文件总是使用 adaptive
+	Dither    bool   // 自适应调色板使用 Floyd–Steinberg 抖动
+}
+
+// newGIFOptions 根据命令行参数确定 GIF 压缩选项
+func newGIFOptions(frameStep, colors int, palette string, dither bool) (gifOptions, error) {
+	if frameStep < 1 {
+		return gifOptions{}, fmt.Errorf("--gif-frame-step 必须大于 0")
+	}
+	if colors < 2 || colors > maxGIFColors {
+		return gifOptions{}, fmt.Errorf("--gif-colors 必须在 2 到 %d 之间", maxGIFColors)
+	}
+	palette = strings.ToLower(palette)
+	if palette != gifPaletteOriginal && palette != gifPaletteAdaptive {
+		return gifOptions{}, fmt.Errorf("不支持的 GIF 调色板模式: %s（可选 %s）", palette, strings.Join(gifPaletteModes, "、"))
+	}
+	return gifOptions{FrameStep: frameStep, Colors: colors, Palette: palette, Dither: dither}, nil
+}
+
+// describe 描述 GIF 选项，用于输出
+func (o gifOptions) describe() string {
+	text := fmt.Sprintf("最多 %d 色", o.Colors)
+	if o.Palette == gifPaletteAdaptive {
+		text += "，自适应调色板"
+		if o.Dither {
+			text += "，抖动"
+		}
+	}
+	if o.FrameStep > 1 {
+		text += fmt.Sprintf("，每 %d 帧保留 1 帧", o.FrameStep)
+	}
+	return text
+}
+
+// gifAnimation GIF 的全部帧
+//
+// image.Decode 只返回第一帧，压缩 GIF 时改用 gif.DecodeAll；作为 image.Image 使用时为合成后的第一帧。
+type gifAnimation struct {
+	image.Image
+	GIF *gif.GIF
+}
+
+// decodeGIFAnimation 解码 GIF 的全部帧
+func decodeGIFAnimation(data []byte) (*gifAnimation, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	anim := &gifAnimation{GIF: g}
+	compositeGIF(g, func(i int, canvas *image.RGBA) bool {
+		anim.Image = canvas
+		return false
+	})
+	return anim, nil
+}
+
+// countGIFFrames 扫描图像描述符统计 GIF 的帧数，不解码图像数据
+//
+// 文件结构损坏时返回已统计的帧数，至少为 1。
+func countGIFFrames(data []byte) int {
+	const headerSize = 13 // 签名 6 字节，逻辑屏幕描述 7 字节
+	if len(data) < headerSize {
+		return 1
+	}
+	frames := 0
+	for i := headerSize + gifColorTableSize(data[10]); i < len(data); {
+		var ok bool
+		switch data[i] {
+		case 0x21:
+			i, ok = gifSubBlocksEnd(data, i+2)
+		case 0x2c:
+			if i+10 > len(data) {
+				break
+			}
+			frames++
+			// 图像描述 10 字节、局部颜色表、LZW 最小码长和图像数据子块
+			i, ok = gifSubBlocksEnd(data, i+10+gifColorTableSize(data[i+9])+1)
+		}
+		if !ok {
+			break
+		}
+	}
+	if frames == 0 {
+		return 1
+	}
+	return frames
+}
+
+// compositeGIF 按处置方式依次合成每一帧，visit 收到的是绘制第 i 帧后的完整画面
+//
+// canvas 在 visit 返回后会继续修改，需要保留时应复制；visit 返回 false 时停止。
+func compositeGIF(g *gif.GIF, visit func(i int, canvas *image.RGBA) bool) {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	var previous []uint8
+	for i, frame := range g.Image {
+		disposal := gifDisposal(g, i)
+		if disposal == gif.DisposalPrevious {
+			previous = append(previous[:0], canvas.Pix...)
+		}
+
+		// 透明色的 alpha 为 0，Over 保留下面的像素
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if !visit(i, canvas) {
+			return
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			// 浏览器统一恢复为透明，而不是背景色
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous)
+		}
+	}
+}
+
+// gifDisposal 第 i 帧的处置方式
+func gifDisposal(g *gif.GIF, i int) byte {
+	if i < len(g.Disposal) {
+		return g.Disposal[i]
+	}
+	return 0
+}
+
+// compressGIFAnimation 缩放 GIF 的每一帧，保留延迟、处置方式和循环次数
+//
+// 每帧先按原处置方式合成完整画面再缩放，避免各帧单独缩放产生接缝；
+// 丢弃的帧的延迟并入前一个保留的帧。
+func compressGIFAnimation(src *gif.GIF, plan resizePlan, opts compressOptions) *gif.GIF {
+	// eachFrame 依次缩放保留的帧
+	eachFrame := func(visit func(i int, resized image.Image)) {
+		compositeGIF(src, func(i int, canvas *image.RGBA) bool {
+			if i%opts.GIF.FrameStep == 0 {
+				visit(i, applyResizePlan(canvas, plan, opts.Filter, transparentBackground))
+			}
+			return true
+		})
+	}
+
+	var quantize func(i int, resized image.Image) *image.Paletted
+	var quantizer *gifQuantizer
+	if opts.GIF.Palette == gifPaletteAdaptive {
+		// 先统计所有保留帧的颜色，生成一个全局调色板，各帧不会因调色板不同而闪烁
+		histogram := newColorHistogram(opts.GIF.Colors)
+		eachFrame(func(_ int, resized image.Image) { histogram.add(gifOpaque(resized)) })
+		palette := gifAdaptivePalette(histogram)
+		quantize = func(_ int, resized image.Image) *image.Paletted {
+			return quantizeImage(gifOpaque(resized), palette, opts.GIF.Dither)
+		}
+	} else {
+		quantizer = &gifQuantizer{palettes: map[string]*gifPalette{}}
+		quantize = func(i int, resized image.Image) *image.Paletted {
+			return quantizer.quantize(resized, src.Image[i].Palette)
+		}
+	}
+
+	var frames []*image.Paletted
+	var delays []int
+	var disposals []byte
+	eachFrame(func(i int, resized image.Image) {
+		frames = append(frames, quantize(i, resized))
+		delays = append(delays, 0)
+		disposals = append(disposals, gifDisposal(src, i))
+	})
+	// 丢弃帧的延迟并入前一个保留帧
+	for i, delay := range src.Delay {
+		delays[i/opts.GIF.FrameStep] += delay
+	}
+	if quantizer != nil {
+		quantizer.reduce(opts.GIF.Colors)
+	}
+
+	size := plan.Size()
+	out := &gif.GIF{
+		Image:     optimizeGIFFrames(frames, disposals),
+		Delay:     delays,
+		Disposal:  disposals,
+		LoopCount: src.LoopCount,
+		Config:    image.Config{Width: size.X, Height: size.Y},
+	}
+
+	// 所有帧共用一个调色板时写成全局颜色表
+	palette := out.Image[0].Palette
+	shared := true
+	for _, frame := range out.Image {
+		shared = shared && &frame.Palette[0] == &palette[0]
+	}
+	if shared {
+		out.Config.ColorModel = palette
+	}
+	return out
+}
+
+// gifPalette 输出调色板：源调色板中去重后的不透明颜色，最后一项为透明色
+type gifPalette struct {
+	Colors color.Palette
+	usage  []int
+	cache  map[uint32]uint8
+}
+
+// transparent 透明色的索引
+func (p *gifPalette) transparent() uint8 {
+	return uint8(len(p.Colors) - 1)
+}
+
+// index 返回最接近的颜色索引，alpha 低于一半时为透明
+func (p *gifPalette) index(c color.NRGBA) uint8 {
+	if c.A < 0x80 {
+		return p.transparent()
+	}
+	key := uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+	if index, ok := p.cache[key]; ok {
+		return index
+	}
+	index := uint8(nearestColor(p.Colors[:len(p.Colors)-1], c))
+	p.cache[key] = index
+	return index
+}
+
+// nearestColor 在不透明颜色中查找最接近的颜色
+func nearestColor(palette color.Palette, c color.NRGBA) int {
+	best, bestDistance := 0, -1
+	for i, candidate := range palette {
+		r, g, b, _ := candidate.RGBA()
+		dr, dg, db := int(r>>8)-int(c.R), int(g>>8)-int(c.G), int(b>>8)-int(c.B)
+		if distance := dr*dr + dg*dg + db*db; bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// gifQuantizer 将缩放后的帧映射到源帧的调色板
+//
+// 内容相同的源调色板共用一个输出调色板，所有帧都使用全局颜色表时输出也只有一个调色板。
+type gifQuantizer struct {
+	palettes map[string]*gifPalette
+	frames   []*image.Paletted
+}
+
+// palette 返回源调色板对应的输出调色板
+func (q *gifQuantizer) palette(source color.Palette) *gifPalette {
+	var key []byte
+	var colors color.Palette
+	seen := map[color.RGBA]bool{}
+	for _, c := range source {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		if rgba.A != 0xff || seen[rgba] || len(colors) == maxGIFColors-1 {
+			continue
+		}
+		seen[rgba] = true
+		colors = append(colors, rgba)
+		key = append(key, rgba.R, rgba.G, rgba.B)
+	}
+	if len(colors) == 0 {
+		// 源调色板只有透明色时用黑色占位
+		colors = append(colors, color.RGBA{A: 0xff})
+	}
+
+	if palette, ok := q.palettes[string(key)]; ok {
+		return palette
+	}
+	palette := &gifPalette{Colors: append(colors, color.RGBA{}), cache: map[uint32]uint8{}}
+	palette.usage = make([]int, len(palette.Colors))
+	q.palettes[string(key)] = palette
+	return palette
+}
+
+// quantize 将图片映射到源调色板，并统计颜色的使用次数
+func (q *gifQuantizer) quantize(img image.Image, source color.Palette) *image.Paletted {
+	bounds := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	}
+
+	palette := q.palette(source)
+	frame := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), palette.Colors)
+	for y := 0; y < bounds.Dy(); y++ {
+		row := rgba.Pix[rgba.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+		for x := 0; x < bounds.Dx(); x++ {
+			// 预乘颜色还原为直通颜色
+			c := color.NRGBA{A: row[x*4+3]}
+			if c.A != 0 {
+				c.R = uint8(uint32(row[x*4]) * 0xff / uint32(c.A))
+				c.G = uint8(uint32(row[x*4+1]) * 0xff / uint32(c.A))
+				c.B = uint8(uint32(row[x*4+2]) * 0xff / uint32(c.A))
+			}
+			index := palette.index(c)
+			frame.Pix[y*frame.Stride+x] = index
+			palette.usage[index]++
+		}
+	}
+	q.frames = append(q.frames, frame)
+	return frame
+}
+
+// reduce 去掉未使用的颜色，并把每个调色板缩减到最多 colors 色（含透明色），保留使用最多的颜色
+func (q *gifQuantizer) reduce(colors int) {
+	for _, palette := range q.palettes {
+		opaque := len(palette.Colors) - 1
+		order := make([]int, 0, opaque)
+		for i := 0; i < opaque; i++ {
+			if palette.usage[i] > 0 {
+				order = append(order, i)
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return palette.usage[order[a]] > palette.usage[order[b]]
+		})
+		if len(order) > colors-1 {
+			order = order[:colors-1]
+		}
+		if len(order) == opaque {
+			continue
+		}
+
+		// 被去掉的颜色映射到保留颜色中最接近的一个
+		reduced := make(color.Palette, 0, len(order)+1)
+		for _, i := range order {
+			reduced = append(reduced, palette.Colors[i])
+		}
+		lookup := make([]uint8, len(palette.Colors))
+		for i := 0; i < opaque; i++ {
+			if len(reduced) == 0 {
+				break
+			}
+			lookup[i] = uint8(nearestColor(reduced, color.NRGBAModel.Convert(palette.Colors[i]).(color.NRGBA)))
+		}
+		lookup[opaque] = uint8(len(reduced))
+		reduced = append(reduced, color.RGBA{})
+
+		for _, frame := range q.frames {
+			if &frame.Palette[0] != &palette.Colors[0] {
+				continue
+			}
+			for i, index := range frame.Pix {
+				frame.Pix[i] = lookup[index]
+			}
+			frame.Palette = reduced
+		}
+		palette.Colors = reduced
+	}
+}
+
+// gifOpaque 将颜色转换为 GIF 能表示的形式：alpha 低于一半时完全透明，否则完全不透明
+func gifOpaque(img image.Image) *image.RGBA {
+	src := toRGBA(img)
+	dst := image.NewRGBA(src.Rect)
+	for y := 0; y < src.Rect.Dy(); y++ {
+		in, out := src.Pix[y*src.Stride:], dst.Pix[y*dst.Stride:]
+		for x := 0; x < src.Rect.Dx()*4; x += 4 {
+			a := uint32(in[x+3])
+			if a < 0x80 {
+				continue
+			}
+			// 预乘颜色还原为不透明颜色
+			out[x] = uint8(uint32(in[x]) * 0xff / a)
+			out[x+1] = uint8(uint32(in[x+1]) * 0xff / a)
+			out[x+2] = uint8(uint32(in[x+2]) * 0xff / a)
+			out[x+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// gifAdaptivePalette 生成动图的自适应调色板，透明色固定在最后，供帧优化写入未变化的像素
+func gifAdaptivePalette(histogram *colorHistogram) color.Palette {
+	histogram.transparent = true
+	palette := histogram.palette(make(color.Palette, 0, histogram.limit))
+	if len(palette) == 1 {
+		// 只有透明像素时用黑色占位
+		palette = append(palette, color.RGBA{A: 0xff})
+	}
+	return append(palette[1:], palette[0])
+}
+
+// optimizeGIFFrames 每帧只写入相对于当前显示内容有变化的区域，区域内未变化的像素写为透明
+//
+// 模拟解码器按处置方式维护显示内容。某帧需要把已显示的不透明像素变为透明时，
+// 绘制无法做到，此时把上一帧改为完整画面并在显示后清除（DisposalBackground）。
+func optimizeGIFFrames(frames []*image.Paletted, disposals []byte) []*image.Paletted {
+	bounds := frames[0].Bounds()
+	shown := make([]color.RGBA, bounds.Dx()*bounds.Dy())
+	out := make([]*image.Paletted, len(frames))
+
+	for k, frame := range frames {
+		colors := make([]color.RGBA, len(frame.Palette))
+		for i, c := range frame.Palette {
+			colors[i] = color.RGBAModel.Convert(c).(color.RGBA)
+		}
+
+		if k > 0 && !gifFrameDrawable(frame, colors, shown) {
+			out[k-1] = frames[k-1]
+			disposals[k-1] = gif.DisposalBackground
+			clear(shown)
+		}
+
+		// 变化区域
+		changed := image.Rectangle{}
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				if colors[frame.Pix[y*frame.Stride+x]] != shown[y*bounds.Dx()+x] {
+					changed = changed.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		if changed.Empty() {
+			changed = image.Rect(0, 0, 1, 1)
+		}
+
+		transparent := uint8(len(frame.Palette) - 1)
+		cropped := image.NewPaletted(changed, frame.Palette)
+		for y := changed.Min.Y; y < changed.Max.Y; y++ {
+			for x := changed.Min.X; x < changed.Max.X; x++ {
+				index := frame.Pix[y*frame.Stride+x]
+				current := &shown[y*bounds.Dx()+x]
+				if colors[index] == *current {
+					index = transparent
+				}
+				cropped.SetColorIndex(x, y, index)
+
+				switch disposals[k] {
+				case gif.DisposalBackground:
+					*current = color.RGBA{}
+				case gif.DisposalPrevious:
+				default:
+					if colors[index].A != 0 {
+						*current = colors[index]
+					}
+				}
+			}
+		}
+		out[k] = cropped
+	}
+	return out
+}
+
+// gifFrameDrawable 帧是否可以直接绘制在当前显示内容上：透明像素只能保留下面的内容
+func gifFrameDrawable(frame *image.Paletted, colors []color.RGBA, shown []color.RGBA) bool {
+	width := frame.Bounds().Dx()
+	for y := 0; y < frame.Bounds().Dy(); y++ {
+		for x := 0; x < width; x++ {
+			if colors[frame.Pix[y*frame.Stride+x]].A == 0 && shown[y*width+x].A != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// testAnimation 生成包含各种处置方式和透明像素的动图
+func testAnimation(t *testing.T) []byte {
+	t.Helper()
+	palette := color.Palette{
+		color.RGBA{R: 0xff, A: 0xff},
+		color.RGBA{B: 0xff, A: 0xff},
+		color.RGBA{G: 0xff, A: 0xff},
+		color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		color.RGBA{},
+	}
+	frame := func(rect image.Rectangle, index uint8) *image.Paletted {
+		m := image.NewPaletted(rect, palette)
+		for i := range m.Pix {
+			m.Pix[i] = index
+		}
+		return m
+	}
+
+	// 第 3 帧带透明像素，露出下面的内容
+	holed := frame(image.Rect(20, 20, 32, 32), 2)
+	for y := 24; y < 28; y++ {
+		for x := 24; x < 28; x++ {
+			holed.SetColorIndex(x, y, 4)
+		}
+	}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 40, 40), 0),
+			frame(image.Rect(8, 8, 20, 20), 1),
+			holed,
+			frame(image.Rect(28, 0, 40, 12), 3),
+		},
+		Delay:     []int{10, 20, 30, 40},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone},
+		LoopCount: 2,
+		Config:    image.Config{ColorModel: palette, Width: 40, Height: 40},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("编码测试动图失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// compositeFrames 合成每一帧的完整画面
+func compositeFrames(g *gif.GIF) []*image.RGBA {
+	var frames []*image.RGBA
+	compositeGIF(g, func(i int, canvas *image.RGBA) bool {
+		frame := image.NewRGBA(canvas.Bounds())
+		copy(frame.Pix, canvas.Pix)
+		frames = append(frames, frame)
+		return true
+	})
+	return frames
+}
+
+// compressTestAnimation 按比率缩放测试动图并重新解码
+func compressTestAnimation(t *testing.T, options gifOptions) (*gif.GIF, *gif.GIF) {
+	t.Helper()
+	img, format, err := decodeImage(testAnimation(t))
+	if err != nil || format != "gif" {
+		t.Fatalf("解码动图失败: %v", err)
+	}
+	anim := img.(*gifAnimation)
+	if len(anim.GIF.Image) != 4 || img.Bounds() != image.Rect(0, 0, 40, 40) {
+		t.Fatalf("应解码全部帧: %d 帧，%v", len(anim.GIF.Image), img.Bounds())
+	}
+
+	opts := compressOptions{Size: sizeOptions{Rate: 0.5}, Filter: resampleFilters["nearest"], GIF: options}
+	var buf bytes.Buffer
+	if err := compressGIF(img, &buf, planResize(40, 40, opts.Size), opts); err != nil {
+		t.Fatalf("压缩动图失败: %v", err)
+	}
+	out, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("解码压缩结果失败: %v", err)
+	}
+	return anim.GIF, out
+}
+
+func TestCompressGIFAnimation(t *testing.T) {
+	src, out := compressTestAnimation(t, gifOptions{FrameStep: 1, Colors: maxGIFColors})
+	if len(out.Image) != 4 || out.LoopCount != 2 || out.Config.Width != 20 || out.Config.Height != 20 {
+		t.Fatalf("帧数、循环次数或尺寸不正确: %d 帧，循环 %d，%dx%d", len(out.Image), out.LoopCount, out.Config.Width, out.Config.Height)
+	}
+	for i, delay := range []int{10, 20, 30, 40} {
+		if out.Delay[i] != delay {
+			t.Errorf("第 %d 帧延迟应为 %d，实际为 %d", i, delay, out.Delay[i])
+		}
+	}
+
+	// 每帧显示的画面与原图缩小后一致
+	want, got := compositeFrames(src), compositeFrames(out)
+	for i := range got {
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 20; x++ {
+				if w, g := want[i].RGBAAt(x*2, y*2), got[i].RGBAAt(x, y); w != g {
+					t.Fatalf("第 %d 帧 (%d,%d) 应为 %v，实际为 %v", i, x, y, w, g)
+				}
+			}
+		}
+	}
+
+	// 只有第一帧是完整画面
+	for i, frame := range out.Image[1:] {
+		if frame.Bounds() == image.Rect(0, 0, 20, 20) {
+			t.Errorf("第 %d 帧应只包含变化区域", i+1)
+		}
+	}
+}
+
+func TestCompressGIFFrameStepAndColors(t *testing.T) {
+	src, out := compressTestAnimation(t, gifOptions{FrameStep: 2, Colors: 2})
+	if len(out.Image) != 2 || out.Delay[0] != 30 || out.Delay[1] != 70 {
+		t.Fatalf("丢帧后延迟应合并: %d 帧，%v", len(out.Image), out.Delay)
+	}
+	for i, frame := range out.Image {
+		if len(frame.Palette) > 2 {
+			t.Errorf("第 %d 帧调色板有 %d 色", i, len(frame.Palette))
+		}
+	}
+
+	// 保留的第 3 帧画面正确（红色背景被缩减为唯一颜色）
+	want := compositeFrames(src)[2]
+	got := compositeFrames(out)[1]
+	if w, g := want.RGBAAt(2, 2), got.RGBAAt(1, 1); w != g {
+		t.Errorf("应为 %v，实际为 %v", w, g)
+	}
+}
+
+func TestOptimizeGIFFramesClearsCanvas(t *testing.T) {
+	palette := color.Palette{color.RGBA{R: 0xff, A: 0xff}, color.RGBA{}}
+	opaque := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	cleared := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	for i := range cleared.Pix {
+		cleared.Pix[i] = 1
+	}
+	cleared.Pix[0] = 0
+
+	// 第 2 帧需要把不透明像素变为透明，只能在第 1 帧显示后清除画布
+	disposals := []byte{gif.DisposalNone, gif.DisposalNone}
+	frames := optimizeGIFFrames([]*image.Paletted{opaque, cleared}, disposals)
+	if disposals[0] != gif.DisposalBackground || frames[0].Bounds() != opaque.Bounds() {
+		t.Errorf("第 1 帧应改为显示后清除: %v, %v", disposals, frames[0].Bounds())
+	}
+	if frames[1].Bounds() != image.Rect(0, 0, 1, 1) {
+		t.Errorf("第 2 帧只需写入一个像素，实际为 %v", frames[1].Bounds())
+	}
+}
+
+func TestNewGIFOptions(t *testing.T) {
+	opts, err := newGIFOptions(1, 64, "Adaptive", false)
+	if err != nil || opts.Palette != gifPaletteAdaptive || opts.Dither {
+		t.Errorf("解析选项不正确: %+v, %v", opts, err)
+	}
+	if _, err := newGIFOptions(1, 64, "plan9", true); err == nil {
+		t.Error("不支持的调色板模式应报错")
+	}
+}
+
+func TestCompressGIFAdaptivePalette(t *testing.T) {
+	src, out := compressTestAnimation(t, gifOptions{FrameStep: 1, Colors: maxGIFColors, Palette: gifPaletteAdaptive, Dither: true})
+	if len(out.Image) != 4 {
+		t.Fatalf("帧数不正确: %d", len(out.Image))
+	}
+
+	// 所有帧共用全局调色板，包含帧优化使用的透明色
+	if _, ok := out.Config.ColorModel.(color.Palette); !ok {
+		t.Fatal("自适应调色板应写成全局颜色表")
+	}
+	transparent := 0
+	for _, c := range out.Image[1].Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			transparent++
+		}
+	}
+	if transparent != 1 {
+		t.Errorf("调色板应有一个透明色: %v", out.Image[1].Palette)
+	}
+
+	// 颜色少于调色板容量，每帧画面与原图缩小后一致
+	want, got := compositeFrames(src), compositeFrames(out)
+	for i := range got {
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 20; x++ {
+				if w, g := want[i].RGBAAt(x*2, y*2), got[i].RGBAAt(x, y); w != g {
+					t.Fatalf("第 %d 帧 (%d,%d) 应为 %v，实际为 %v", i, x, y, w, g)
+				}
+			}
+		}
+	}
+}
+
+func TestCompressGIFStillImage(t *testing.T) {
+	// Plan9 调色板中没有的颜色，左上角半透明、右下角几乎透明
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{0x12, 0x34, 0x56, 0xff})
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{R: 0xff, A: 0xc0})
+	img.SetNRGBA(15, 15, color.NRGBA{R: 0xff, A: 0x10})
+
+	for _, dither := range []bool{false, true} {
+		opts := compressOptions{Size: sizeOptions{Rate: 1}, Filter: resampleFilters["nearest"], GIF: gifOptions{Colors: 16, Dither: dither}}
+		var buf bytes.Buffer
+		if err := compressGIF(img, &buf, planResize(16, 16, opts.Size), opts); err != nil {
+			t.Fatalf("压缩失败: %v", err)
+		}
+		out, err := gif.Decode(&buf)
+		if err != nil {
+			t.Fatalf("解码失败: %v", err)
+		}
+
+		if got := color.RGBAModel.Convert(out.At(8, 8)); got != (color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}) {
+			t.Errorf("颜色应保持不变，实际为 %v", got)
+		}
+		if got := color.RGBAModel.Convert(out.At(0, 0)); got != (color.RGBA{R: 0xff, A: 0xff}) {
+			t.Errorf("alpha 超过一半的像素应不透明，实际为 %v", got)
+		}
+		if _, _, _, a := out.At(15, 15).RGBA(); a != 0 {
+			t.Errorf("alpha 低于一半的像素应透明")
+		}
+	}
+}
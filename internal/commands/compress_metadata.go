@@ -0,0 +1,606 @@
+package commands
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// 元数据处理方式
+const (
+	metadataStrip    = "strip"     // 全部移除
+	metadataKeep     = "keep"      // 全部保留
+	metadataKeepSafe = "keep-safe" // 只保留色彩配置和版权，移除 GPS 位置等隐私信息
+)
+
+// metadataPolicies --metadata 可选的处理方式
+var metadataPolicies = []string{metadataStrip, metadataKeep, metadataKeepSafe}
+
+// EXIF 标签
+const (
+	exifTagOrientation = 0x0112
+	exifTagCopyright   = 0x8298
+	exifTagGPS         = 0x8825
+)
+
+// exifTypeSizes EXIF 各数据类型的字节数
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// 各容器中元数据的标识
+var (
+	jpegEXIFHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	jpegICCHeader  = []byte("ICC_PROFILE\x00")
+	jpegIPTCHeader = []byte("Photoshop 3.0\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// pngXMPKeyword PNG iTXt 中 XMP 的关键字
+const pngXMPKeyword = "XML:com.adobe.xmp"
+
+// JPEG 单个段的最大数据长度（长度字段本身占 2 字节）
+const jpegMaxSegment = 0xffff - 2
+
+// xmpOrientationPattern XMP 中的方向属性，旋转像素后改为 1
+var xmpOrientationPattern = regexp.MustCompile(`(tiff:Orientation(?:="|>))[1-8]`)
+
+// orientationNames EXIF 方向对应的变换说明
+var orientationNames = map[int]string{
+	2: "水平翻转",
+	3: "旋转 180°",
+	4: "垂直翻转",
+	5: "转置",
+	6: "顺时针旋转 90°",
+	7: "反转置",
+	8: "逆时针旋转 90°",
+}
+
+// imageMetadata 从源图片读取的元数据
+type imageMetadata struct {
+	Orientation int    // EXIF 方向 1-8，0 表示未指定
+	EXIF        []byte // TIFF 结构的 EXIF 数据（不含 Exif 前缀）
+	XMP         []byte // XMP 数据包
+	ICC         []byte // ICC 色彩配置
+	IPTC        []byte // JPEG APP13 中的 Photoshop/IPTC 数据
+}
+
+// parseMetadataPolicy 解析 --metadata
+func parseMetadataPolicy(name string) (string, error) {
+	name = strings.ToLower(name)
+	for _, policy := range metadataPolicies {
+		if name == policy {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的元数据处理方式: %s（可选 %s）", name, strings.Join(metadataPolicies, "、"))
+}
+
+// readMetadata 读取 JPEG、PNG、WebP 的 EXIF/XMP/ICC 和 TIFF 的方向，格式不完整时忽略无法识别的部分
+func readMetadata(data []byte, format string) imageMetadata {
+	var meta imageMetadata
+	switch format {
+	case "jpeg":
+		meta = readJPEGMetadata(data)
+	case "png":
+		meta = readPNGMetadata(data)
+	case "webp":
+		meta = readWebPMetadata(data)
+	case "tiff":
+		// TIFF 文件本身就是 EXIF 的结构，只读取方向
+		meta.Orientation = exifOrientation(data)
+		return meta
+	}
+	meta.Orientation = exifOrientation(meta.EXIF)
+	return meta
+}
+
+// readJPEGMetadata 遍历 JPEG 图像数据之前的 APP 段
+func readJPEGMetadata(data []byte) imageMetadata {
+	var meta imageMetadata
+	icc := map[byte][]byte{}
+	iccCount := byte(0)
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		if marker == 0xff {
+			// 标记前可以有任意个 0xFF 填充字节
+			i++
+			continue
+		}
+		if marker == 0x01 || marker >= 0xd0 && marker <= 0xd7 {
+			// 不带长度的标记
+			i += 2
+			continue
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			break
+		}
+		payload := data[i+4 : end]
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(payload, jpegEXIFHeader) && meta.EXIF == nil:
+			meta.EXIF = payload[len(jpegEXIFHeader):]
+		case marker == 0xe1 && bytes.HasPrefix(payload, jpegXMPHeader) && meta.XMP == nil:
+			meta.XMP = payload[len(jpegXMPHeader):]
+		case marker == 0xe2 && bytes.HasPrefix(payload, jpegICCHeader) && len(payload) > len(jpegICCHeader)+2:
+			// ICC 可能分为多段，每段带序号和总段数
+			seq := payload[len(jpegICCHeader)]
+			iccCount = payload[len(jpegICCHeader)+1]
+			icc[seq] = payload[len(jpegICCHeader)+2:]
+		case marker == 0xed && bytes.HasPrefix(payload, jpegIPTCHeader) && meta.IPTC == nil:
+			meta.IPTC = payload
+		}
+		i = end
+	}
+
+	// 只有 1 到总段数的每一段都存在时才拼接，缺段的 ICC 不完整，直接丢弃
+	for seq := 1; seq <= int(iccCount); seq++ {
+		chunk, ok := icc[byte(seq)]
+		if !ok {
+			meta.ICC = nil
+			break
+		}
+		meta.ICC = append(meta.ICC, chunk...)
+	}
+	return meta
+}
+
+// readPNGMetadata 读取 eXIf、iCCP 和 XMP 的 iTXt 块
+func readPNGMetadata(data []byte) imageMetadata {
+	var meta imageMetadata
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		kind := string(data[i+4 : i+8])
+		end := i + 8 + length + 4
+		if length < 0 || end > len(data) {
+			break
+		}
+		chunk := data[i+8 : i+8+length]
+		switch kind {
+		case "eXIf":
+			meta.EXIF = chunk
+		case "iCCP":
+			// 配置名称、压缩方式、zlib 压缩的配置
+			if name := bytes.IndexByte(chunk, 0); name >= 0 && name+2 <= len(chunk) {
+				meta.ICC, _ = inflate(chunk[name+2:])
+			}
+		case "iTXt":
+			meta.XMP = readPNGXMP(chunk, meta.XMP)
+		case "IEND":
+			return meta
+		}
+		i = end
+	}
+	return meta
+}
+
+// readPNGXMP 解析 iTXt 块，关键字为 XMP 时返回其内容
+func readPNGXMP(chunk, current []byte) []byte {
+	keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+	if !ok || string(keyword) != pngXMPKeyword || len(rest) < 2 {
+		return current
+	}
+	compressed := rest[0] == 1
+	// 跳过压缩标记、压缩方式、语言标签和翻译后的关键字
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0})
+	if !ok {
+		return current
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0})
+	if !ok {
+		return current
+	}
+	if compressed {
+		xmp, err := inflate(text)
+		if err != nil {
+			return current
+		}
+		return xmp
+	}
+	return text
+}
+
+// readWebPMetadata 读取 RIFF 容器中的 EXIF、XMP 和 ICCP 块
+func readWebPMetadata(data []byte) imageMetadata {
+	var meta imageMetadata
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size
+		if size < 0 || end > len(data) {
+			break
+		}
+		chunk := data[i+8 : end]
+		switch string(data[i : i+4]) {
+		case "EXIF":
+			meta.EXIF = bytes.TrimPrefix(chunk, jpegEXIFHeader)
+		case "XMP ":
+			meta.XMP = chunk
+		case "ICCP":
+			meta.ICC = chunk
+		}
+		// 块按偶数字节对齐
+		i = end + size%2
+	}
+	return meta
+}
+
+// inflate 解压 zlib 数据
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// exifEntry IFD 中的一个标签
+type exifEntry struct {
+	Tag    uint16
+	Type   uint16
+	Count  uint32
+	Offset int // 标签在数据中的位置
+}
+
+// exifIFD0 解析 EXIF 的字节序和第一个 IFD 的标签
+func exifIFD0(data []byte) (binary.ByteOrder, []exifEntry) {
+	if len(data) < 8 {
+		return nil, nil
+	}
+	var order binary.ByteOrder
+	switch string(data[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil, nil
+	}
+
+	offset := int(order.Uint32(data[4:]))
+	if offset < 8 || offset+2 > len(data) {
+		return nil, nil
+	}
+	count := int(order.Uint16(data[offset:]))
+	var entries []exifEntry
+	for i := 0; i < count; i++ {
+		pos := offset + 2 + i*12
+		if pos+12 > len(data) {
+			break
+		}
+		entries = append(entries, exifEntry{
+			Tag:    order.Uint16(data[pos:]),
+			Type:   order.Uint16(data[pos+2:]),
+			Count:  order.Uint32(data[pos+4:]),
+			Offset: pos,
+		})
+	}
+	return order, entries
+}
+
+// exifValue 返回标签的原始值，不超过 4 字节的值直接存放在标签中
+func exifValue(data []byte, order binary.ByteOrder, entry exifEntry) []byte {
+	size := uint64(exifTypeSizes[entry.Type]) * uint64(entry.Count)
+	start := uint64(entry.Offset + 8)
+	if size > 4 {
+		start = uint64(order.Uint32(data[entry.Offset+8:]))
+	}
+	if start+size > uint64(len(data)) {
+		return nil
+	}
+	return data[start : start+size]
+}
+
+// findEXIFEntry 在 IFD0 中查找标签
+func findEXIFEntry(entries []exifEntry, tag uint16) (exifEntry, bool) {
+	for _, entry := range entries {
+		if entry.Tag == tag {
+			return entry, true
+		}
+	}
+	return exifEntry{}, false
+}
+
+// exifOrientation 读取 EXIF 方向，无效时返回 0
+func exifOrientation(data []byte) int {
+	order, entries := exifIFD0(data)
+	entry, ok := findEXIFEntry(entries, exifTagOrientation)
+	if !ok || entry.Type != 3 || entry.Count != 1 {
+		return 0
+	}
+	orientation := int(order.Uint16(data[entry.Offset+8:]))
+	if orientation < 1 || orientation > 8 {
+		return 0
+	}
+	return orientation
+}
+
+// hasGPS 判断 EXIF 是否包含 GPS 位置
+func (m imageMetadata) hasGPS() bool {
+	_, entries := exifIFD0(m.EXIF)
+	_, ok := findEXIFEntry(entries, exifTagGPS)
+	return ok
+}
+
+// copyright 读取 EXIF 中的版权信息
+func (m imageMetadata) copyright() []byte {
+	order, entries := exifIFD0(m.EXIF)
+	entry, ok := findEXIFEntry(entries, exifTagCopyright)
+	if !ok || entry.Type != 2 {
+		return nil
+	}
+	return bytes.TrimRight(exifValue(m.EXIF, order, entry), "\x00")
+}
+
+// names 列出包含的元数据
+func (m imageMetadata) names() []string {
+	var names []string
+	if len(m.EXIF) > 0 {
+		names = append(names, "EXIF")
+	}
+	if len(m.XMP) > 0 {
+		names = append(names, "XMP")
+	}
+	if len(m.ICC) > 0 {
+		names = append(names, "ICC")
+	}
+	if len(m.IPTC) > 0 {
+		names = append(names, "IPTC")
+	}
+	return names
+}
+
+// apply 按处理方式筛选要写入输出文件的元数据；像素已按方向旋转，保留的方向改为 1
+func (m imageMetadata) apply(policy string) imageMetadata {
+	switch policy {
+	case metadataKeep:
+		kept := imageMetadata{EXIF: m.EXIF, XMP: m.XMP, ICC: m.ICC, IPTC: m.IPTC}
+		if m.Orientation > 1 {
+			kept.EXIF = resetEXIFOrientation(m.EXIF)
+		}
+		if len(m.XMP) > 0 {
+			kept.XMP = xmpOrientationPattern.ReplaceAll(m.XMP, []byte("${1}1"))
+		}
+		return kept
+	case metadataKeepSafe:
+		// XMP 和 IPTC 可能包含位置，只从 EXIF 中取出版权重新生成
+		kept := imageMetadata{ICC: m.ICC}
+		if copyright := m.copyright(); len(copyright) > 0 {
+			kept.EXIF = copyrightEXIF(copyright)
+		}
+		return kept
+	}
+	return imageMetadata{}
+}
+
+// resetEXIFOrientation 复制 EXIF 并将方向改为 1
+func resetEXIFOrientation(data []byte) []byte {
+	order, entries := exifIFD0(data)
+	entry, ok := findEXIFEntry(entries, exifTagOrientation)
+	if !ok {
+		return data
+	}
+	reset := bytes.Clone(data)
+	order.PutUint16(reset[entry.Offset+8:], 1)
+	return reset
+}
+
+// copyrightEXIF 生成只包含版权的 EXIF
+func copyrightEXIF(copyright []byte) []byte {
+	value := append(bytes.Clone(copyright), 0)
+	order := binary.LittleEndian
+	// 文件头 8 字节，IFD 包含 1 个标签，共 2+12+4 字节
+	data := make([]byte, 26, 26+len(value))
+	copy(data, "II*\x00")
+	order.PutUint32(data[4:], 8)
+	order.PutUint16(data[8:], 1)
+	order.PutUint16(data[10:], exifTagCopyright)
+	order.PutUint16(data[12:], 2)
+	order.PutUint32(data[14:], uint32(len(value)))
+	if len(value) <= 4 {
+		copy(data[18:22], value)
+		return data
+	}
+	order.PutUint32(data[18:], 26)
+	return append(data, value...)
+}
+
+// embedMetadata 将元数据写入编码后的 JPEG 或 PNG，返回写入的元数据名称；其他格式不写入
+func embedMetadata(data []byte, format string, meta imageMetadata) ([]byte, []string) {
+	switch format {
+	case "jpeg":
+		return embedJPEGMetadata(data, meta)
+	case "png":
+		return embedPNGMetadata(data, meta)
+	}
+	return data, nil
+}
+
+// embedJPEGMetadata 在 SOI 之后插入 APP 段
+func embedJPEGMetadata(data []byte, meta imageMetadata) ([]byte, []string) {
+	var segments bytes.Buffer
+	var names []string
+	writeSegment := func(marker byte, parts ...[]byte) bool {
+		size := 0
+		for _, part := range parts {
+			size += len(part)
+		}
+		if size > jpegMaxSegment {
+			return false
+		}
+		segments.Write([]byte{0xff, marker, byte((size + 2) >> 8), byte(size + 2)})
+		for _, part := range parts {
+			segments.Write(part)
+		}
+		return true
+	}
+
+	if len(meta.EXIF) > 0 && writeSegment(0xe1, jpegEXIFHeader, meta.EXIF) {
+		names = append(names, "EXIF")
+	}
+	if len(meta.XMP) > 0 && writeSegment(0xe1, jpegXMPHeader, meta.XMP) {
+		names = append(names, "XMP")
+	}
+	if len(meta.ICC) > 0 {
+		// ICC 超过单段长度时拆分为多段
+		chunkSize := jpegMaxSegment - len(jpegICCHeader) - 2
+		count := (len(meta.ICC) + chunkSize - 1) / chunkSize
+		if count <= 0xff {
+			for seq := 0; seq < count; seq++ {
+				chunk := meta.ICC[seq*chunkSize : min((seq+1)*chunkSize, len(meta.ICC))]
+				writeSegment(0xe2, jpegICCHeader, []byte{byte(seq + 1), byte(count)}, chunk)
+			}
+			names = append(names, "ICC")
+		}
+	}
+	if len(meta.IPTC) > 0 && writeSegment(0xed, meta.IPTC) {
+		names = append(names, "IPTC")
+	}
+
+	if segments.Len() == 0 || len(data) < 2 {
+		return data, names
+	}
+	output := make([]byte, 0, len(data)+segments.Len())
+	output = append(output, data[:2]...)
+	output = append(output, segments.Bytes()...)
+	return append(output, data[2:]...), names
+}
+
+// embedPNGMetadata 在 IHDR 之后插入 iCCP、eXIf 和 iTXt 块
+func embedPNGMetadata(data []byte, meta imageMetadata) ([]byte, []string) {
+	var chunks bytes.Buffer
+	var names []string
+	writeChunk := func(kind string, parts ...[]byte) {
+		size := 0
+		for _, part := range parts {
+			size += len(part)
+		}
+		crc := crc32.NewIEEE()
+		binary.Write(&chunks, binary.BigEndian, uint32(size))
+		w := io.MultiWriter(&chunks, crc)
+		io.WriteString(w, kind)
+		for _, part := range parts {
+			w.Write(part)
+		}
+		binary.Write(&chunks, binary.BigEndian, crc.Sum32())
+	}
+
+	if len(meta.ICC) > 0 {
+		var profile bytes.Buffer
+		zw, _ := zlib.NewWriterLevel(&profile, zlib.BestCompression)
+		zw.Write(meta.ICC)
+		zw.Close()
+		writeChunk("iCCP", []byte("ICC Profile\x00\x00"), profile.Bytes())
+		names = append(names, "ICC")
+	}
+	if len(meta.EXIF) > 0 {
+		writeChunk("eXIf", meta.EXIF)
+		names = append(names, "EXIF")
+	}
+	if len(meta.XMP) > 0 {
+		// 未压缩，语言标签和翻译关键字为空
+		writeChunk("iTXt", []byte(pngXMPKeyword+"\x00\x00\x00\x00\x00"), meta.XMP)
+		names = append(names, "XMP")
+	}
+
+	// 签名 8 字节 + IHDR 块 25 字节
+	const ihdrEnd = 33
+	if chunks.Len() == 0 || len(data) < ihdrEnd {
+		return data, names
+	}
+	output := make([]byte, 0, len(data)+chunks.Len())
+	output = append(output, data[:ihdrEnd]...)
+	output = append(output, chunks.Bytes()...)
+	return append(output, data[ihdrEnd:]...), names
+}
+
+// metadataSize 写入元数据大约增加的字节数，目标大小模式下从预算中扣除
+func metadataSize(meta imageMetadata) int64 {
+	const overhead = 64
+	return int64(len(meta.EXIF) + len(meta.XMP) + len(meta.ICC) + len(meta.IPTC) + overhead)
+}
+
+// orientImage 按 EXIF 方向旋转或翻转图片，使像素与显示方向一致
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	// 输出的 (x, y) 对应源图片的坐标
+	var source func(x, y int) (int, int)
+	dw, dh := w, h
+	switch orientation {
+	case 2:
+		source = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3:
+		source = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4:
+		source = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5:
+		source = func(x, y int) (int, int) { return y, x }
+	case 6:
+		source = func(x, y int) (int, int) { return y, h - 1 - x }
+	case 7:
+		source = func(x, y int) (int, int) { return w - 1 - y, h - 1 - x }
+	case 8:
+		source = func(x, y int) (int, int) { return w - 1 - y, x }
+	}
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		row := dst.Pix[y*dst.Stride:]
+		for x := 0; x < dw; x++ {
+			sx, sy := source(x, y)
+			copy(row[x*4:x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// describe 说明源图片元数据的保留和移除情况，written 为实际写入的元数据
+func (m imageMetadata) describe(policy string, written []string) string {
+	kept := map[string]bool{}
+	for i, name := range written {
+		kept[name] = true
+		if name == "EXIF" && policy == metadataKeepSafe {
+			written[i] = "版权"
+			kept[name] = false
+		}
+	}
+
+	var removed []string
+	for _, name := range m.names() {
+		if kept[name] {
+			continue
+		}
+		if name == "EXIF" && m.hasGPS() {
+			name += "（含 GPS 位置）"
+		}
+		removed = append(removed, name)
+	}
+
+	var parts []string
+	if len(written) > 0 {
+		parts = append(parts, "保留 "+strings.Join(written, "、"))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "移除 "+strings.Join(removed, "、"))
+	}
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testEXIF 生成包含方向、版权和 GPS 位置的 EXIF
+func testEXIF(orientation uint16) []byte {
+	order := binary.LittleEndian
+	copyright := []byte("(c) cyber-zen\x00")
+	// 文件头 8 字节，IFD0 有 3 个标签，之后是版权字符串和 GPS IFD
+	ifdEnd := 8 + 2 + 3*12 + 4
+	gpsOffset := ifdEnd + len(copyright)
+	data := make([]byte, gpsOffset+2+12+4)
+	copy(data, "II*\x00")
+	order.PutUint32(data[4:], 8)
+	order.PutUint16(data[8:], 3)
+	entry := func(i int, tag, typ uint16, count, value uint32) {
+		pos := 10 + i*12
+		order.PutUint16(data[pos:], tag)
+		order.PutUint16(data[pos+2:], typ)
+		order.PutUint32(data[pos+4:], count)
+		order.PutUint32(data[pos+8:], value)
+	}
+	entry(0, exifTagOrientation, 3, 1, uint32(orientation))
+	entry(1, exifTagCopyright, 2, uint32(len(copyright)), uint32(ifdEnd))
+	entry(2, exifTagGPS, 4, 1, uint32(gpsOffset))
+	copy(data[ifdEnd:], copyright)
+	// GPS IFD：GPSLatitudeRef = "N"
+	order.PutUint16(data[gpsOffset:], 1)
+	order.PutUint16(data[gpsOffset+2:], 1)
+	order.PutUint16(data[gpsOffset+4:], 2)
+	order.PutUint32(data[gpsOffset+6:], 2)
+	data[gpsOffset+10] = 'N'
+	return data
+}
+
+// testPhoto 生成 4x2 的 JPEG，左半红色、右半蓝色，带 EXIF、XMP 和 ICC
+func testPhoto(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.RGBA{R: 0xff, A: 0xff}
+			if x >= 8 {
+				c = color.RGBA{B: 0xff, A: 0xff}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("编码测试图片失败: %v", err)
+	}
+	data, _ := embedJPEGMetadata(buf.Bytes(), imageMetadata{
+		EXIF: testEXIF(orientation),
+		XMP:  []byte(`<x:xmpmeta tiff:Orientation="6" exif:GPSLatitude="31,12N"/>`),
+		ICC:  bytes.Repeat([]byte("icc"), 30000),
+	})
+	return data
+}
+
+func TestReadJPEGMetadata(t *testing.T) {
+	meta := readMetadata(testPhoto(t, 6), "jpeg")
+	if meta.Orientation != 6 || !meta.hasGPS() || string(meta.copyright()) != "(c) cyber-zen" {
+		t.Fatalf("EXIF 读取不正确: 方向 %d，GPS %v，版权 %q", meta.Orientation, meta.hasGPS(), meta.copyright())
+	}
+	// ICC 超过单段长度，拆分后应完整拼回
+	if len(meta.ICC) != 90000 || !bytes.Contains(meta.XMP, []byte("GPSLatitude")) {
+		t.Errorf("ICC 或 XMP 读取不正确: %d 字节 ICC，XMP %q", len(meta.ICC), meta.XMP)
+	}
+	if got := strings.Join(meta.names(), ","); got != "EXIF,XMP,ICC" {
+		t.Errorf("元数据列表不正确: %s", got)
+	}
+
+	// 标记前的 0xFF 填充字节个数为奇数时也能找到 EXIF
+	photo := testPhoto(t, 6)
+	for _, fill := range []int{1, 2, 3} {
+		padded := append(append(append([]byte{}, photo[:2]...), bytes.Repeat([]byte{0xff}, fill)...), photo[2:]...)
+		if got := readMetadata(padded, "jpeg"); got.Orientation != 6 || !got.hasGPS() {
+			t.Errorf("%d 个填充字节: 方向 %d，GPS %v", fill, got.Orientation, got.hasGPS())
+		}
+	}
+
+	// 总段数为 255 时序号不能回绕；缺少任何一段时丢弃 ICC
+	iccSegments := func(count int, skip int) []byte {
+		data := []byte{0xff, 0xd8}
+		for seq := 1; seq <= count; seq++ {
+			if seq == skip {
+				continue
+			}
+			payload := append(append([]byte{}, jpegICCHeader...), byte(seq), byte(count), 'c')
+			data = append(data, 0xff, 0xe2, byte((len(payload)+2)>>8), byte(len(payload)+2))
+			data = append(data, payload...)
+		}
+		return append(data, 0xff, 0xd9)
+	}
+	if icc := readJPEGMetadata(iccSegments(255, 0)).ICC; len(icc) != 255 {
+		t.Errorf("255 段 ICC 应完整拼回，实际为 %d 字节", len(icc))
+	}
+	if icc := readJPEGMetadata(iccSegments(255, 128)).ICC; icc != nil {
+		t.Errorf("缺段的 ICC 应丢弃，实际为 %d 字节", len(icc))
+	}
+}
+
+func TestMetadataPolicies(t *testing.T) {
+	meta := readMetadata(testPhoto(t, 6), "jpeg")
+
+	if got := meta.apply(metadataStrip); len(got.names()) != 0 {
+		t.Errorf("strip 应移除全部元数据: %v", got.names())
+	}
+
+	keep := meta.apply(metadataKeep)
+	if exifOrientation(keep.EXIF) != 1 || !keep.hasGPS() || !bytes.Contains(keep.XMP, []byte(`tiff:Orientation="1"`)) {
+		t.Errorf("keep 应保留全部元数据并将方向改为 1")
+	}
+	if exifOrientation(meta.EXIF) != 6 {
+		t.Error("不应修改源数据")
+	}
+
+	safe := meta.apply(metadataKeepSafe)
+	if safe.hasGPS() || len(safe.XMP) > 0 || len(safe.ICC) != len(meta.ICC) || string(safe.copyright()) != "(c) cyber-zen" {
+		t.Errorf("keep-safe 应只保留 ICC 和版权: %v", safe.names())
+	}
+	if got := meta.describe(metadataKeepSafe, []string{"EXIF", "ICC"}); got != "保留 版权、ICC，移除 EXIF（含 GPS 位置）、XMP" {
+		t.Errorf("说明不正确: %s", got)
+	}
+}
+
+func TestEmbedPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, randomNRGBA(8, 8, false))
+	meta := imageMetadata{EXIF: copyrightEXIF([]byte("me")), XMP: []byte("<xmp/>"), ICC: []byte("profile")}
+	data, written := embedPNGMetadata(buf.Bytes(), meta)
+	if len(written) != 3 {
+		t.Fatalf("应写入 3 项元数据: %v", written)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("写入元数据后无法解码: %v", err)
+	}
+	got := readMetadata(data, "png")
+	if string(got.copyright()) != "me" || string(got.XMP) != "<xmp/>" || string(got.ICC) != "profile" {
+		t.Errorf("读回的元数据不正确: %+v", got)
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// 3x2 图片，每个像素的红色通道为其序号
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		img.Pix[i*4] = uint8(i)
+	}
+	rows := func(m image.Image) string {
+		var b strings.Builder
+		bounds := m.Bounds()
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				r, _, _, _ := m.At(x, y).RGBA()
+				b.WriteByte('0' + byte(r>>8))
+			}
+			b.WriteByte('/')
+		}
+		return b.String()
+	}
+
+	for orientation, want := range map[int]string{
+		1: "012/345/",
+		2: "210/543/",
+		3: "543/210/",
+		4: "345/012/",
+		5: "03/14/25/",
+		6: "30/41/52/",
+		7: "52/41/30/",
+		8: "25/14/03/",
+	} {
+		if got := rows(orientImage(img, orientation)); got != want {
+			t.Errorf("方向 %d: 期望 %s，实际为 %s", orientation, want, got)
+		}
+	}
+}
+
+func TestCompressImageFileOrientationAndMetadata(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(src, testPhoto(t, 6), 0644); err != nil {
+		t.Fatalf("写入测试图片失败: %v", err)
+	}
+
+	for _, policy := range metadataPolicies {
+		opts := compressOptions{Size: sizeOptions{Rate: 1}, Filter: resampleFilters[defaultResampleFilter], Metadata: policy}
+		dist := filepath.Join(dir, policy+".jpg")
+		var out bytes.Buffer
+		if _, err := compressImageFile(src, dist, opts, compressLog{&out}); err != nil {
+			t.Fatalf("%s: 压缩失败: %v", policy, err)
+		}
+		data, _ := os.ReadFile(dist)
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: 无法解码输出: %v", policy, err)
+		}
+
+		// 顺时针旋转 90° 后为 8x16，红色在上
+		if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 16 {
+			t.Fatalf("%s: 应按方向旋转，实际尺寸 %v", policy, img.Bounds())
+		}
+		if r, _, b, _ := img.At(4, 2).RGBA(); r>>8 < 200 || b>>8 > 50 {
+			t.Errorf("%s: 上半部分应为红色", policy)
+		}
+		if !strings.Contains(out.String(), "方向: 按 EXIF 顺时针旋转 90°") {
+			t.Errorf("%s: 应提示已旋转:\n%s", policy, out.String())
+		}
+
+		meta := readMetadata(data, "jpeg")
+		switch policy {
+		case metadataStrip:
+			if len(meta.names()) != 0 {
+				t.Errorf("strip 输出不应有元数据: %v", meta.names())
+			}
+		case metadataKeep:
+			if meta.Orientation != 1 || !meta.hasGPS() || len(meta.XMP) == 0 {
+				t.Errorf("keep 输出应保留全部元数据，方向为 1: %v", meta.names())
+			}
+		case metadataKeepSafe:
+			if meta.hasGPS() || len(meta.XMP) > 0 || len(meta.ICC) == 0 || string(meta.copyright()) != "(c) cyber-zen" {
+				t.Errorf("keep-safe 输出应只有 ICC 和版权: %v", meta.names())
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// maxPNGColors PNG8 调色板最多的颜色数
+const maxPNGColors = 256
+
+// defaultPNGMinPSNR 量化结果的默认最低峰值信噪比（dB），低于该值时保留真彩色
+const defaultPNGMinPSNR = 30
+
+// pngOptions PNG 压缩选项
+type pngOptions struct {
+	Colors  int     // PNG8 调色板的颜色数（含透明色），0 表示保留真彩色
+	Dither  bool    // 量化时使用 Floyd–Steinberg 抖动
+	MinPSNR float64 // 量化结果的最低峰值信噪比（dB），0 表示不检查
+}
+
+// newPNGOptions 根据命令行参数确定 PNG 压缩选项
+func newPNGOptions(colors int, dither bool, minPSNR float64) (pngOptions, error) {
+	if colors != 0 && (colors < 2 || colors > maxPNGColors) {
+		return pngOptions{}, fmt.Errorf("--png-colors 必须在 2 到 %d 之间（0 表示不量化）", maxPNGColors)
+	}
+	if minPSNR < 0 {
+		return pngOptions{}, fmt.Errorf("--png-min-psnr 不能为负数")
+	}
+	return pngOptions{Colors: colors, Dither: dither, MinPSNR: minPSNR}, nil
+}
+
+// describe 描述 PNG 选项，用于输出
+func (o pngOptions) describe() string {
+	if o.Colors == 0 {
+		return "真彩色"
+	}
+	text := fmt.Sprintf("PNG8，最多 %d 色", o.Colors)
+	if o.Dither {
+		text += "，抖动"
+	}
+	if o.MinPSNR > 0 {
+		text += fmt.Sprintf("，最低 %.1f dB", o.MinPSNR)
+	}
+	return text
+}
+
+// pngEncoder PNG8 模式使用最高压缩级别
+var pngEncoder = png.Encoder{CompressionLevel: png.BestCompression}
+
+// compressPNG8 将缩放后的图片量化为调色板 PNG，误差超过阈值时以真彩色编码
+func compressPNG8(img image.Image, w io.Writer, opts pngOptions) error {
+	palette := medianCutQuantizer{}.Quantize(make(color.Palette, 0, opts.Colors), img)
+	paletted := quantizeImage(img, palette, opts.Dither)
+	if opts.MinPSNR > 0 && quantizePSNR(img, paletted) < opts.MinPSNR {
+		return pngEncoder.Encode(w, img)
+	}
+	return pngEncoder.Encode(w, paletted)
+}
+
+// pngQuantized 判断 PNG 是否为调色板格式（IHDR 的颜色类型为 3）
+func pngQuantized(data []byte) bool {
+	// 签名 8 字节，IHDR 长度和类型 8 字节，宽高 8 字节，位深 1 字节
+	const colorTypeOffset = 25
+	return bytes.HasPrefix(data, pngSignature) && len(data) > colorTypeOffset && data[colorTypeOffset] == 3
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestNewPNGOptions(t *testing.T) {
+	if _, err := newPNGOptions(0, true, defaultPNGMinPSNR); err != nil {
+		t.Errorf("0 表示不量化: %v", err)
+	}
+	for _, colors := range []int{1, 257, -1} {
+		if _, err := newPNGOptions(colors, true, 0); err == nil {
+			t.Errorf("%d 色应报错", colors)
+		}
+	}
+	if _, err := newPNGOptions(256, true, -1); err == nil {
+		t.Error("负的 PSNR 应报错")
+	}
+}
+
+func TestMedianCutQuantizer(t *testing.T) {
+	// 颜色少于调色板容量时无损，透明像素单独占用透明色
+	few := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	few.SetNRGBA(1, 0, color.NRGBA{R: 0xff, A: 0xff})
+	few.SetNRGBA(2, 0, color.NRGBA{B: 0xff, A: 0x80})
+	few.SetNRGBA(3, 0, color.NRGBA{G: 0xff, A: 0xff})
+	palette := medianCutQuantizer{}.Quantize(make(color.Palette, 0, 8), few)
+	if len(palette) != 4 || palette[0] != (color.RGBA{}) {
+		t.Fatalf("调色板应为透明色加 3 种原色: %v", palette)
+	}
+	if psnr := quantizePSNR(few, quantizeImage(few, palette, true)); !math.IsInf(psnr, 1) {
+		t.Errorf("应无损，实际为 %.1f dB", psnr)
+	}
+
+	// 颜色多于容量时不超过容量，误差随颜色数增加而减小
+	img := randomNRGBA(64, 64, true)
+	previous := 0.0
+	for _, colors := range []int{4, 16, 64, 256} {
+		palette := medianCutQuantizer{}.Quantize(make(color.Palette, 0, colors), img)
+		if len(palette) > colors {
+			t.Fatalf("%d 色调色板有 %d 色", colors, len(palette))
+		}
+		psnr := quantizePSNR(img, quantizeImage(img, palette, false))
+		if psnr <= previous {
+			t.Errorf("%d 色的误差应更小: %.1f dB <= %.1f dB", colors, psnr, previous)
+		}
+		previous = psnr
+	}
+}
+
+func TestQuantizeImageDither(t *testing.T) {
+	// 灰度渐变只用黑白两色，抖动后各区域的平均灰度接近原图
+	img := image.NewGray(image.Rect(0, 0, 256, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 256; x++ {
+			img.Pix[y*img.Stride+x] = uint8(x)
+		}
+	}
+	palette := color.Palette{color.RGBA{A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}}
+	// x 到 x+32 列的平均灰度
+	regionMean := func(m *image.Paletted, x int) float64 {
+		sum := 0
+		for y := 0; y < 32; y++ {
+			for i := x; i < x+32; i++ {
+				sum += int(m.Pix[y*m.Stride+i]) * 255
+			}
+		}
+		return float64(sum) / 32 / 32
+	}
+
+	flat, dithered := quantizeImage(img, palette, false), quantizeImage(img, palette, true)
+	if regionMean(flat, 48) != 0 {
+		t.Errorf("不抖动时应映射到最接近的黑色")
+	}
+	for _, x := range []int{48, 112, 176} {
+		if mean, want := regionMean(dithered, x), float64(x+16); math.Abs(mean-want) > 8 {
+			t.Errorf("抖动后 %d 列附近的平均灰度应接近 %.0f，实际为 %.1f", x+16, want, mean)
+		}
+	}
+}
+
+func TestCompressPNG8(t *testing.T) {
+	// 半透明渐变
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: 0x80, A: uint8(x * 4)})
+		}
+	}
+	encode := func(png pngOptions) []byte {
+		opts := compressOptions{Size: sizeOptions{Rate: 1}, Filter: resampleFilters[defaultResampleFilter], PNG: png}
+		var buf bytes.Buffer
+		if err := compressPNG(img, &buf, planResize(64, 64, opts.Size), opts); err != nil {
+			t.Fatalf("压缩失败: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	data := encode(pngOptions{Colors: 64, Dither: true, MinPSNR: 20})
+	if !pngQuantized(data) {
+		t.Fatal("应输出调色板 PNG")
+	}
+	out, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	paletted := out.(*image.Paletted)
+	if len(paletted.Palette) > 64 {
+		t.Errorf("调色板有 %d 色", len(paletted.Palette))
+	}
+	if _, _, _, a := paletted.At(0, 10).RGBA(); a != 0 {
+		t.Errorf("透明像素应保持透明，alpha 为 %d", a)
+	}
+
+	// 误差超过阈值时保留真彩色
+	if pngQuantized(encode(pngOptions{Colors: 4, MinPSNR: 40})) {
+		t.Error("4 色误差过大，应保留真彩色")
+	}
+}
@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// defaultMaxMemory 并发压缩的默认内存预算
+const defaultMaxMemory = "1GB"
+
+// compressLog 单个文件的输出，并发压缩时先写入缓冲区，再按顺序整体输出
+type compressLog struct {
+	w io.Writer
+}
+
+func (l compressLog) print(attribute color.Attribute, format string, args ...interface{}) {
+	color.New(attribute).Fprintf(l.w, format+"\n", args...)
+}
+
+func (l compressLog) green(format string, args ...interface{}) {
+	l.print(color.FgGreen, format, args...)
+}
+
+func (l compressLog) cyan(format string, args ...interface{}) {
+	l.print(color.FgCyan, format, args...)
+}
+
+func (l compressLog) yellow(format string, args ...interface{}) {
+	l.print(color.FgYellow, format, args...)
+}
+
+func (l compressLog) red(format string, args ...interface{}) {
+	l.print(color.FgRed, format, args...)
+}
+
+// compressResult 单个文件的压缩结果
+type compressResult struct {
+	Path           string // 实际写入的路径，转换格式时扩展名会改变
+	OriginalSize   int64
+	CompressedSize int64
+	KeptOriginal   bool  // 重新编码节省不足，写入的是原文件内容（可能已去掉元数据段）
+	EncodedSize    int64 // 重新编码后的大小，保留原文件时与 CompressedSize 不同
+}
+
+// memoryBudget 按估算的内存占用限制同时处理的图片
+//
+// 单张图片超过预算时等其他图片处理完后单独处理，不会一直阻塞。
+type memoryBudget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	budget := &memoryBudget{limit: limit}
+	budget.cond = sync.NewCond(&budget.mu)
+	return budget
+}
+
+// acquire 占用 size 字节的预算，预算不足时等待
+func (b *memoryBudget) acquire(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used > 0 && b.used+size > b.limit {
+		b.cond.Wait()
+	}
+	b.used += size
+}
+
+// release 归还 acquire 占用的预算
+func (b *memoryBudget) release(size int64) {
+	b.mu.Lock()
+	b.used -= size
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// estimateImageMemory 估算压缩一张图片需要的内存：源文件和输出、解码后的像素、缩放结果
+//
+// 需要旋转的图片另有两份原尺寸的 RGBA 副本；GIF 按帧数计算，DecodeConfig 只描述一帧。
+func estimateImageMemory(path string, opts compressOptions) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	fileSize := int64(len(data))
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// 无法解码的文件直接复制
+		return fileSize
+	}
+
+	const bytesPerPixel = 4
+	pixels := int64(config.Width) * int64(config.Height)
+	output := planResize(config.Width, config.Height, opts.Size).Size()
+	outputPixels := int64(output.X) * int64(output.Y)
+	memory := fileSize*2 + pixels*bytesPerPixel + outputPixels*bytesPerPixel*2
+
+	if readMetadata(data, format).Orientation > 1 {
+		// orientImage 先复制原图再写入旋转后的图片
+		memory += pixels * bytesPerPixel * 2
+	}
+	if format == "gif" {
+		frames := int64(countGIFFrames(data))
+		step := int64(opts.GIF.FrameStep)
+		if step < 1 {
+			step = 1
+		}
+		kept := (frames + step - 1) / step
+		// 解码后每帧一个调色板图像，压缩时另有一块合成画布，每个保留帧输出一个调色板图像
+		memory += frames*pixels + pixels*bytesPerPixel + kept*outputPixels
+	}
+	return memory
+}
+
+// compressJob 目录压缩中的单个文件
+type compressJob struct {
+	Src  string
+	Dist string
+	Rel  string // 相对于源目录的路径，用于输出
+}
+
+// compressOutcome 单个文件的处理结果和缓存的输出
+type compressOutcome struct {
+	Result compressResult
+	Err    error
+	Output bytes.Buffer
+}
+
+// compressFailure 压缩失败的文件
+type compressFailure struct {
+	Rel string
+	Err error
+}
+
+// compressSummary 目录压缩汇总
+type compressSummary struct {
+	Files           int
+	OriginalBytes   int64
+	CompressedBytes int64
+	Failures        []compressFailure
+	Kept            []compressKept
+	Elapsed         time.Duration
+}
+
+// compressKept 保留原文件内容的文件
+type compressKept struct {
+	Rel    string
+	Saving float64 // 重新编码节省的百分比
+}
+
+// runCompressJobs 使用 opts.Jobs 个 worker 并发压缩，按 jobs 的顺序输出每个文件的结果
+func runCompressJobs(jobs []compressJob, opts compressOptions, out io.Writer) compressSummary {
+	started := time.Now()
+	budget := newMemoryBudget(opts.MaxMemory)
+	outcomes := make([]chan *compressOutcome, len(jobs))
+	for i := range outcomes {
+		outcomes[i] = make(chan *compressOutcome, 1)
+	}
+
+	indexes := make(chan int)
+	go func() {
+		for i := range jobs {
+			indexes <- i
+		}
+		close(indexes)
+	}()
+
+	for worker := 0; worker < min(opts.Jobs, len(jobs)); worker++ {
+		go func() {
+			for i := range indexes {
+				job := jobs[i]
+				outcome := &compressOutcome{}
+				log := compressLog{w: &outcome.Output}
+				log.cyan("压缩: %s", job.Rel)
+
+				memory := estimateImageMemory(job.Src, opts)
+				budget.acquire(memory)
+				outcome.Result, outcome.Err = compressImageFile(job.Src, job.Dist, opts, log)
+				budget.release(memory)
+
+				if outcome.Err != nil {
+					log.red("压缩失败: %s - %v", job.Rel, outcome.Err)
+				}
+				outcomes[i] <- outcome
+			}
+		}()
+	}
+
+	summary := compressSummary{Files: len(jobs)}
+	for i, job := range jobs {
+		outcome := <-outcomes[i]
+		out.Write(outcome.Output.Bytes())
+		if outcome.Err != nil {
+			summary.Failures = append(summary.Failures, compressFailure{Rel: job.Rel, Err: outcome.Err})
+			continue
+		}
+		summary.OriginalBytes += outcome.Result.OriginalSize
+		summary.CompressedBytes += outcome.Result.CompressedSize
+		if outcome.Result.KeptOriginal {
+			saving := savingsPercent(outcome.Result.CompressedSize, outcome.Result.EncodedSize)
+			summary.Kept = append(summary.Kept, compressKept{Rel: job.Rel, Saving: saving})
+		}
+	}
+	summary.Elapsed = time.Since(started)
+	return summary
+}
+
+// print 输出汇总信息
+func (s compressSummary) print() {
+	color.Green("压缩汇总:")
+	color.Cyan("  文件: %d 个，成功 %d 个（保留原文件 %d 个），失败 %d 个", s.Files, s.Files-len(s.Failures), len(s.Kept), len(s.Failures))
+	color.Cyan("  原始大小: %s", formatByteSize(s.OriginalBytes))
+	color.Cyan("  压缩大小: %s", formatByteSize(s.CompressedBytes))
+	if s.OriginalBytes > 0 {
+		saved := s.OriginalBytes - s.CompressedBytes
+		color.Cyan("  节省: %s（%.2f%%）", formatByteSize(saved), float64(saved)/float64(s.OriginalBytes)*100)
+	}
+	color.Cyan("  耗时: %s", s.Elapsed.Round(time.Millisecond))
+	for _, kept := range s.Kept {
+		color.Yellow("  = %s: 保留原文件，重新编码节省 %.2f%%", kept.Rel, kept.Saving)
+	}
+	for _, failure := range s.Failures {
+		color.Red("  ✗ %s: %v", failure.Rel, failure.Err)
+	}
+}
+
+// describeJobs 描述并发参数，用于输出
+func describeJobs(opts compressOptions) string {
+	return fmt.Sprintf("%d 个并发，内存预算 %s", opts.Jobs, formatByteSize(opts.MaxMemory))
+}
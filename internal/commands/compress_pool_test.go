@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	budget := newMemoryBudget(100)
+	budget.acquire(60)
+
+	var acquired atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		budget.acquire(60)
+		acquired.Store(true)
+		budget.release(60)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if acquired.Load() {
+		t.Fatal("预算不足时应等待")
+	}
+	budget.release(60)
+	<-done
+
+	// 超过预算的单张图片在空闲时可以单独处理
+	budget.acquire(500)
+	budget.release(500)
+}
+
+func TestEstimateImageMemory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		return path
+	}
+	opts := compressOptions{Size: sizeOptions{Rate: 1}}
+
+	// 40x40 的 4 帧动图：每帧一个调色板图像、一块额外的画布和每个保留帧的输出
+	animation := testAnimation(t)
+	if frames := countGIFFrames(animation); frames != 4 {
+		t.Errorf("期望 4 帧，实际为 %d", frames)
+	}
+	if frames := countGIFFrames(animation[:len(animation)/2]); frames < 1 || frames > 4 {
+		t.Errorf("截断的动图应返回已统计的帧数，实际为 %d", frames)
+	}
+	gifPath := write("anim.gif", animation)
+	base := int64(len(animation))*2 + 1600*4 + 1600*4*2
+	if got, want := estimateImageMemory(gifPath, opts), base+4*1600+1600*4+4*1600; got != want {
+		t.Errorf("动图期望估算 %d 字节，实际为 %d", want, got)
+	}
+	opts.GIF.FrameStep = 2
+	if got, want := estimateImageMemory(gifPath, opts), base+4*1600+1600*4+2*1600; got != want {
+		t.Errorf("每 2 帧保留 1 帧时期望估算 %d 字节，实际为 %d", want, got)
+	}
+
+	// 需要旋转的 16x8 照片多两份原尺寸副本
+	upright := estimateImageMemory(write("upright.jpg", testPhoto(t, 1)), opts)
+	rotated := estimateImageMemory(write("rotated.jpg", testPhoto(t, 6)), opts)
+	if rotated-upright != 16*8*4*2 {
+		t.Errorf("旋转应多估算 %d 字节，实际多 %d", 16*8*4*2, rotated-upright)
+	}
+}
+
+func TestRunCompressJobsOrderedSummary(t *testing.T) {
+	srcDir, distDir := t.TempDir(), t.TempDir()
+	var jobs []compressJob
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("%02d.png", i)
+		file, err := os.Create(filepath.Join(srcDir, name))
+		if err != nil {
+			t.Fatalf("创建图片失败: %v", err)
+		}
+		png.Encode(file, randomNRGBA(40+i*10, 30, true))
+		file.Close()
+
+		dist := filepath.Join(distDir, name)
+		if i == 5 {
+			// 目标目录不存在，写入失败
+			dist = filepath.Join(distDir, "missing", name)
+		}
+		jobs = append(jobs, compressJob{Src: filepath.Join(srcDir, name), Dist: dist, Rel: name})
+	}
+
+	opts := compressOptions{
+		Size:      sizeOptions{Rate: 0.5},
+		Filter:    resampleFilters[defaultResampleFilter],
+		Jobs:      4,
+		MaxMemory: 64 << 10,
+	}
+	var out bytes.Buffer
+	summary := runCompressJobs(jobs, opts, &out)
+
+	if summary.Files != 12 || len(summary.Failures) != 1 || summary.Failures[0].Rel != "05.png" {
+		t.Errorf("汇总不正确: %+v", summary)
+	}
+	if summary.OriginalBytes == 0 || summary.CompressedBytes == 0 {
+		t.Errorf("汇总大小不正确: %+v", summary)
+	}
+
+	// 每个文件的输出按顺序整体出现
+	var order []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if name, ok := strings.CutPrefix(line, "压缩: "); ok {
+			order = append(order, name)
+		} else if strings.HasPrefix(line, "✓ 压缩完成: ") && !strings.HasSuffix(line, order[len(order)-1]) {
+			t.Errorf("%s 的输出与其他文件交错", line)
+		}
+	}
+	for i, name := range order {
+		if name != jobs[i].Rel {
+			t.Fatalf("输出顺序不正确: %v", order)
+		}
+	}
+}
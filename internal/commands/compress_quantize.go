@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// quantizeBits 中位切分统计颜色时每个通道保留的位数
+const quantizeBits = 5
+
+// medianCutQuantizer 中位切分量化器，实现 draw.Quantizer
+type medianCutQuantizer struct{}
+
+// Quantize 向 p 追加最多 cap(p)-len(p) 种颜色
+func (medianCutQuantizer) Quantize(p color.Palette, m image.Image) color.Palette {
+	h := newColorHistogram(cap(p) - len(p))
+	h.add(m)
+	return h.palette(p)
+}
+
+// colorHistogram 中位切分的颜色统计，可以累加多张图片（如动图的所有帧）后生成一个调色板
+//
+// 按每通道 5 位统计颜色，反复从像素最多、跨度最大的颜色盒中按像素数中位切开，每个盒取像素的平均色。
+// 颜色数不超过调色板容量时直接使用原色；完全透明的像素单独占用一个透明色。
+type colorHistogram struct {
+	buckets     map[[4]uint8]*colorBucket
+	exact       map[color.RGBA]bool // 不超过 limit 种颜色时记录原色，超过后为 nil
+	limit       int
+	transparent bool // 是否需要透明色
+}
+
+// colorBucket 一个 5 位颜色格子中的像素
+type colorBucket struct {
+	key   [4]uint8  // 各通道的高 5 位
+	count int       // 像素数
+	sum   [4]uint64 // 各通道（预乘 alpha）之和
+}
+
+// colorBox 中位切分中的一个颜色盒
+type colorBox struct {
+	buckets []*colorBucket
+	count   int
+}
+
+// newColorHistogram 创建颜色统计，limit 为调色板容量
+func newColorHistogram(limit int) *colorHistogram {
+	return &colorHistogram{buckets: map[[4]uint8]*colorBucket{}, exact: map[color.RGBA]bool{}, limit: limit}
+}
+
+// add 统计图片的颜色
+func (h *colorHistogram) add(m image.Image) {
+	rgba := toRGBA(m)
+	for y := 0; y < rgba.Rect.Dy(); y++ {
+		row := rgba.Pix[y*rgba.Stride : y*rgba.Stride+rgba.Rect.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			c := color.RGBA{R: row[i], G: row[i+1], B: row[i+2], A: row[i+3]}
+			if c.A == 0 {
+				h.transparent = true
+				continue
+			}
+			if h.exact != nil {
+				h.exact[c] = true
+				if len(h.exact) > h.limit {
+					h.exact = nil
+				}
+			}
+			key := [4]uint8{c.R >> (8 - quantizeBits), c.G >> (8 - quantizeBits), c.B >> (8 - quantizeBits), c.A >> (8 - quantizeBits)}
+			bucket := h.buckets[key]
+			if bucket == nil {
+				bucket = &colorBucket{key: key}
+				h.buckets[key] = bucket
+			}
+			bucket.count++
+			bucket.sum[0] += uint64(c.R)
+			bucket.sum[1] += uint64(c.G)
+			bucket.sum[2] += uint64(c.B)
+			bucket.sum[3] += uint64(c.A)
+		}
+	}
+}
+
+// palette 向 p 追加最多 cap(p)-len(p) 种颜色，需要透明色时透明色在最前
+func (h *colorHistogram) palette(p color.Palette) color.Palette {
+	colors := cap(p) - len(p)
+	if colors <= 0 {
+		return p
+	}
+	if h.transparent {
+		p = append(p, color.RGBA{})
+		colors--
+	}
+	if h.exact != nil && len(h.exact) <= colors {
+		// 颜色足够少，无损
+		start := len(p)
+		for c := range h.exact {
+			p = append(p, c)
+		}
+		sortPalette(p[start:])
+		return p
+	}
+	if colors <= 0 || len(h.buckets) == 0 {
+		return p
+	}
+
+	all := colorBox{}
+	for _, bucket := range h.buckets {
+		all.buckets = append(all.buckets, bucket)
+		all.count += bucket.count
+	}
+	boxes := []colorBox{all}
+	for len(boxes) < colors {
+		// 优先切分像素多且颜色跨度大的盒
+		best, bestScore := -1, 0
+		for i, box := range boxes {
+			if len(box.buckets) < 2 {
+				continue
+			}
+			if _, span := box.widestChannel(); span*box.count > bestScore {
+				best, bestScore = i, span*box.count
+			}
+		}
+		if best < 0 {
+			break
+		}
+		low, high := boxes[best].split()
+		boxes[best] = low
+		boxes = append(boxes, high)
+	}
+
+	start := len(p)
+	for _, box := range boxes {
+		p = append(p, box.average())
+	}
+	sortPalette(p[start:])
+	return p
+}
+
+// widestChannel 返回颜色跨度最大的通道和跨度
+func (b colorBox) widestChannel() (int, int) {
+	channel, span := 0, -1
+	for c := 0; c < 4; c++ {
+		lo, hi := uint8(math.MaxUint8), uint8(0)
+		for _, bucket := range b.buckets {
+			lo, hi = min(lo, bucket.key[c]), max(hi, bucket.key[c])
+		}
+		if int(hi)-int(lo) > span {
+			channel, span = c, int(hi)-int(lo)
+		}
+	}
+	return channel, span
+}
+
+// split 沿跨度最大的通道按像素数中位切成两个盒
+func (b colorBox) split() (colorBox, colorBox) {
+	channel, _ := b.widestChannel()
+	sort.Slice(b.buckets, func(i, j int) bool { return b.buckets[i].key[channel] < b.buckets[j].key[channel] })
+
+	// 两边至少各有一个格子
+	cut, count := 1, b.buckets[0].count
+	for cut < len(b.buckets)-1 && count+b.buckets[cut].count <= b.count/2 {
+		count += b.buckets[cut].count
+		cut++
+	}
+	return colorBox{buckets: b.buckets[:cut], count: count}, colorBox{buckets: b.buckets[cut:], count: b.count - count}
+}
+
+// average 盒中像素的平均色
+func (b colorBox) average() color.Color {
+	var sum [4]uint64
+	for _, bucket := range b.buckets {
+		for c := range sum {
+			sum[c] += bucket.sum[c]
+		}
+	}
+	n := uint64(b.count)
+	return color.RGBA{R: uint8((sum[0] + n/2) / n), G: uint8((sum[1] + n/2) / n), B: uint8((sum[2] + n/2) / n), A: uint8((sum[3] + n/2) / n)}
+}
+
+// sortPalette 按亮度排序调色板，使相同图片的输出稳定
+func sortPalette(p color.Palette) {
+	sort.Slice(p, func(i, j int) bool {
+		a, b := p[i].(color.RGBA), p[j].(color.RGBA)
+		if a.A != b.A {
+			return a.A < b.A
+		}
+		la, lb := 299*int(a.R)+587*int(a.G)+114*int(a.B), 299*int(b.R)+587*int(b.G)+114*int(b.B)
+		if la != lb {
+			return la < lb
+		}
+		return uint32(a.R)<<16|uint32(a.G)<<8|uint32(a.B) < uint32(b.R)<<16|uint32(b.G)<<8|uint32(b.B)
+	})
+}
+
+// paletteMapper 查找调色板中最接近的颜色（预乘 alpha 的 RGBA 距离），缓存查找结果
+type paletteMapper struct {
+	colors []color.RGBA
+	cache  map[color.RGBA]uint8
+}
+
+// newPaletteMapper 创建调色板查找器
+func newPaletteMapper(palette color.Palette) *paletteMapper {
+	m := &paletteMapper{colors: make([]color.RGBA, len(palette)), cache: map[color.RGBA]uint8{}}
+	for i, c := range palette {
+		m.colors[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+	return m
+}
+
+// index 返回最接近的颜色索引
+func (m *paletteMapper) index(c color.RGBA) uint8 {
+	if index, ok := m.cache[c]; ok {
+		return index
+	}
+	best, bestDistance := 0, -1
+	for i, candidate := range m.colors {
+		dr, dg, db, da := int(candidate.R)-int(c.R), int(candidate.G)-int(c.G), int(candidate.B)-int(c.B), int(candidate.A)-int(c.A)
+		if distance := dr*dr + dg*dg + db*db + da*da; bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	m.cache[c] = uint8(best)
+	return uint8(best)
+}
+
+// quantizeImage 将图片映射到调色板，dither 时使用 Floyd–Steinberg 误差扩散
+//
+// draw.FloydSteinberg 每个像素都要线性查找调色板，这里缓存查找结果，速度快一个数量级。
+func quantizeImage(img image.Image, palette color.Palette, dither bool) *image.Paletted {
+	rgba := toRGBA(img)
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	paletted := image.NewPaletted(img.Bounds(), palette)
+	mapper := newPaletteMapper(palette)
+
+	// 当前行和下一行的累积误差，两端各留一个像素，放大 16 倍存储
+	current, next := make([][4]int32, w+2), make([][4]int32, w+2)
+	for y := 0; y < h; y++ {
+		src := rgba.Pix[y*rgba.Stride:]
+		dst := paletted.Pix[y*paletted.Stride:]
+		for x := 0; x < w; x++ {
+			// 完全透明的像素不接收也不扩散误差
+			if dither && src[x*4+3] == 0 {
+				dst[x] = mapper.index(color.RGBA{})
+				continue
+			}
+			var value [4]int32
+			for ch := range value {
+				value[ch] = int32(src[x*4+ch])
+				if dither {
+					value[ch] = min(max(value[ch]+(current[x+1][ch]+8)>>4, 0), 255)
+				}
+			}
+			c := color.RGBA{R: uint8(value[0]), G: uint8(value[1]), B: uint8(value[2]), A: uint8(value[3])}
+			index := mapper.index(c)
+			dst[x] = index
+			if !dither {
+				continue
+			}
+
+			q := mapper.colors[index]
+			for ch, v := range [4]uint8{q.R, q.G, q.B, q.A} {
+				e := value[ch] - int32(v)
+				current[x+2][ch] += e * 7
+				next[x][ch] += e * 3
+				next[x+1][ch] += e * 5
+				next[x+2][ch] += e
+			}
+		}
+		current, next = next, current
+		clear(next)
+	}
+	return paletted
+}
+
+// quantizePSNR 计算量化结果相对原图的峰值信噪比（dB），完全一致时为 +Inf
+func quantizePSNR(img image.Image, paletted *image.Paletted) float64 {
+	rgba := toRGBA(img)
+	colors := newPaletteMapper(paletted.Palette).colors
+
+	var sum float64
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*rgba.Stride + x*4
+			q := colors[paletted.Pix[y*paletted.Stride+x]]
+			for c, v := range []uint8{q.R, q.G, q.B, q.A} {
+				d := float64(rgba.Pix[i+c]) - float64(v)
+				sum += d * d
+			}
+		}
+	}
+	if sum == 0 || w*h == 0 {
+		return math.Inf(1)
+	}
+	mse := sum / float64(w*h*4)
+	return 10 * math.Log10(255*255/mse)
+}
+
+// toRGBA 转换为从 (0, 0) 开始的 *image.RGBA
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+	return rgba
+}
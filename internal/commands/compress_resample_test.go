@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// genericImage 隐藏具体类型，强制走通用读取路径
+type genericImage struct {
+	image.Image
+}
+
+// randomNRGBA 生成随机图片
+func randomNRGBA(width, height int, opaque bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rng.Read(img.Pix)
+	if opaque {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 255
+		}
+	}
+	return img
+}
+
+func TestResampleSpansNormalized(t *testing.T) {
+	for name, filter := range resampleFilters {
+		if filter.Kernel == nil {
+			continue
+		}
+		for _, size := range [][2]int{{100, 37}, {37, 100}, {5, 1}, {1, 5}} {
+			for i, span := range resampleSpans(size[0], size[1], filter) {
+				var sum float32
+				for _, w := range span.Weights {
+					sum += w
+				}
+				if math.Abs(float64(sum-1)) > 1e-4 {
+					t.Fatalf("%s %v 第 %d 个像素的权重和为 %f", name, size, i, sum)
+				}
+				if span.Start < 0 || span.Start+len(span.Weights) > size[0] {
+					t.Fatalf("%s %v 第 %d 个像素越界: %+v", name, size, i, span)
+				}
+			}
+		}
+	}
+}
+
+func TestResampleLinearLight(t *testing.T) {
+	// 黑白棋盘格缩小一半，线性光平均后应为 sRGB 188 左右，而不是 128
+	src := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	dst := resampleImage(src, 32, 32, resampleFilters["box"])
+	if got := dst.RGBAAt(16, 16).R; got < 186 || got > 190 {
+		t.Errorf("期望约 188，实际为 %d", got)
+	}
+}
+
+func TestResampleTransparentEdges(t *testing.T) {
+	// 左半不透明红色、右半完全透明的黑色，缩小后边缘不应出现暗色
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	dst := resampleImage(src, 15, 5, resampleFilters["lanczos3"])
+	for x := 0; x < 15; x++ {
+		c := color.NRGBAModel.Convert(dst.At(x, 2)).(color.NRGBA)
+		if c.A > 16 && (c.R < 250 || c.G > 4 || c.B > 4) {
+			t.Errorf("第 %d 列出现色晕: %+v", x, c)
+		}
+	}
+}
+
+func TestResampleFastPathsMatchGeneric(t *testing.T) {
+	nrgba := randomNRGBA(67, 41, false)
+	rgba := image.NewRGBA(nrgba.Bounds())
+	opaque := randomNRGBA(67, 41, true)
+	ycbcr := image.NewYCbCr(opaque.Bounds(), image.YCbCrSubsampleRatio420)
+	for y := 0; y < 41; y++ {
+		for x := 0; x < 67; x++ {
+			rgba.Set(x, y, nrgba.At(x, y))
+			c := opaque.NRGBAAt(x, y)
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			ycbcr.Y[ycbcr.YOffset(x, y)] = yy
+			ycbcr.Cb[ycbcr.COffset(x, y)] = cb
+			ycbcr.Cr[ycbcr.COffset(x, y)] = cr
+		}
+	}
+
+	filter := resampleFilters["catmull-rom"]
+	for name, img := range map[string]image.Image{"rgba": rgba, "nrgba": nrgba, "ycbcr": ycbcr} {
+		fast := resampleImage(img, 30, 19, filter)
+		generic := resampleImage(genericImage{img}, 30, 19, filter)
+		for i := range fast.Pix {
+			if diff := int(fast.Pix[i]) - int(generic.Pix[i]); diff > 2 || diff < -2 {
+				t.Fatalf("%s 快速路径与通用路径结果不一致: 第 %d 字节 %d != %d", name, i, fast.Pix[i], generic.Pix[i])
+			}
+		}
+	}
+}
+
+func TestResizeImageKeepsSize(t *testing.T) {
+	img := randomNRGBA(10, 10, true)
+	if got := resizeImage(img, 10, 10, resampleFilters["lanczos3"]); got != image.Image(img) {
+		t.Error("尺寸不变时应直接返回原图")
+	}
+	if _, err := parseResampleFilter("cubic"); err == nil {
+		t.Error("未知滤波器应报错")
+	}
+}
+
+// BenchmarkResize 比较各滤波器与原最近邻实现（nearest）的速度
+func BenchmarkResize(b *testing.B) {
+	opaque := randomNRGBA(1024, 768, true)
+	rgba := image.NewRGBA(opaque.Bounds())
+	copy(rgba.Pix, opaque.Pix)
+	ycbcr := image.NewYCbCr(opaque.Bounds(), image.YCbCrSubsampleRatio420)
+
+	images := []struct {
+		Name  string
+		Image image.Image
+	}{{"rgba", rgba}, {"nrgba", opaque}, {"ycbcr", ycbcr}}
+	for _, filterName := range []string{"nearest", "box", "bilinear", "catmull-rom", "lanczos3"} {
+		filter := resampleFilters[filterName]
+		for _, img := range images {
+			b.Run(filterName+"/"+img.Name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					resizeImage(img.Image, 512, 384, filter)
+				}
+			})
+		}
+	}
+}
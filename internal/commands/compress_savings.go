@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"image"
+)
+
+// defaultMinSavings 重新编码至少节省的百分比，不足时保留原文件内容
+const defaultMinSavings = 5
+
+// validateMinSavings 检查 --min-savings
+func validateMinSavings(percent float64) error {
+	if percent < 0 || percent >= 100 {
+		return fmt.Errorf("--min-savings 必须在 0 到 100 之间（不含 100）")
+	}
+	return nil
+}
+
+// savingsPercent 重新编码节省的百分比，变大时为负数
+func savingsPercent(originalSize, compressedSize int64) float64 {
+	if originalSize == 0 {
+		return 0
+	}
+	return float64(originalSize-compressedSize) / float64(originalSize) * 100
+}
+
+// reusableOriginal 返回可以代替重新编码结果的原文件内容，不能代替时返回原因
+//
+// 输出与原文件的格式、尺寸必须相同，否则会违背 --format 或尺寸参数的要求。除 --metadata keep 外，
+// 原文件总是先按白名单去掉元数据段再写入保留的元数据，即使没有识别出元数据，避免泄露未知段中的 GPS 位置；
+// EXIF 方向需要旋转像素时不能代替。结果还需满足 --target-size。
+func reusableOriginal(srcData []byte, format, distFormat string, original, output image.Point, meta, kept imageMetadata, opts compressOptions) ([]byte, string) {
+	if format != distFormat {
+		return nil, fmt.Sprintf("转换为 %s 格式", distFormat)
+	}
+	if original != output {
+		return nil, "尺寸已改变"
+	}
+	data := srcData
+	if opts.Metadata != metadataKeep {
+		if meta.Orientation > 1 {
+			return nil, "需要按 EXIF 方向旋转像素"
+		}
+		stripped, ok := stripMetadata(srcData, format)
+		if !ok {
+			return nil, fmt.Sprintf("无法移除 %s 的元数据", format)
+		}
+		data, _ = embedMetadata(stripped, format, kept)
+	}
+	if opts.Target.Size > 0 && int64(len(data)) > opts.Target.Size {
+		return nil, "原文件超过目标大小"
+	}
+	return data, ""
+}
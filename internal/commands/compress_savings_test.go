@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSavingsPercent(t *testing.T) {
+	if got := savingsPercent(200, 150); got != 25 {
+		t.Errorf("应节省 25%%，实际为 %.2f", got)
+	}
+	if got := savingsPercent(100, 120); got != -20 {
+		t.Errorf("变大时应为负数，实际为 %.2f", got)
+	}
+	for _, percent := range []float64{-1, 100} {
+		if err := validateMinSavings(percent); err == nil {
+			t.Errorf("%.0f 应报错", percent)
+		}
+	}
+}
+
+func TestReusableOriginal(t *testing.T) {
+	size := image.Pt(100, 50)
+	var encoded bytes.Buffer
+	jpeg.Encode(&encoded, randomNRGBA(8, 8, false), nil)
+	plain := encoded.Bytes()
+	photo := testPhoto(t, 1)
+	meta := readMetadata(photo, "jpeg")
+	strip := compressOptions{Metadata: metadataStrip}
+	keep := compressOptions{Metadata: metadataKeep}
+	safe := compressOptions{Metadata: metadataKeepSafe}
+	target := compressOptions{Target: targetOptions{Size: 1000}, Metadata: metadataKeep}
+
+	tests := []struct {
+		name       string
+		src        []byte
+		distFormat string
+		output     image.Point
+		meta       imageMetadata
+		opts       compressOptions
+		reusable   bool
+	}{
+		{"格式和尺寸不变", plain, "jpeg", size, imageMetadata{}, strip, true},
+		{"转换格式", plain, "png", size, imageMetadata{}, strip, false},
+		{"缩小尺寸", plain, "jpeg", image.Pt(50, 25), imageMetadata{}, strip, false},
+		{"移除元数据", photo, "jpeg", size, meta, strip, true},
+		{"保留元数据", photo, "jpeg", size, meta, keep, true},
+		{"需要按方向旋转", photo, "jpeg", size, readMetadata(testPhoto(t, 6), "jpeg"), strip, false},
+		{"原文件超过目标大小", photo, "jpeg", size, meta, target, false},
+		{"无法移除 TIFF 的元数据", plain, "tiff", size, imageMetadata{}, strip, false},
+		{"原文件满足目标大小", plain, "jpeg", size, imageMetadata{}, target, true},
+	}
+	for _, tt := range tests {
+		format := "jpeg"
+		if tt.distFormat == "tiff" {
+			format = "tiff"
+		}
+		data, blocker := reusableOriginal(tt.src, format, tt.distFormat, size, tt.output, tt.meta, tt.meta.apply(tt.opts.Metadata), tt.opts)
+		if (data != nil) != tt.reusable || (blocker == "") != tt.reusable {
+			t.Errorf("%s: 期望可保留 %v，实际为 %d 字节，原因 %q", tt.name, tt.reusable, len(data), blocker)
+		}
+	}
+
+	// 移除元数据后只剩图像数据，keep-safe 只写回 ICC 和版权
+	stripped, _ := reusableOriginal(photo, "jpeg", "jpeg", size, size, meta, meta.apply(metadataStrip), strip)
+	if names := readMetadata(stripped, "jpeg").names(); len(names) != 0 || len(stripped) >= len(photo) {
+		t.Errorf("strip 应移除原文件的全部元数据: %v，%d 字节", names, len(stripped))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("移除元数据后应能解码: %v", err)
+	}
+	kept, _ := reusableOriginal(photo, "jpeg", "jpeg", size, size, meta, meta.apply(metadataKeepSafe), safe)
+	if got := readMetadata(kept, "jpeg"); got.hasGPS() || len(got.XMP) > 0 || len(got.ICC) != len(meta.ICC) || string(got.copyright()) != "(c) cyber-zen" {
+		t.Errorf("keep-safe 应只保留 ICC 和版权: %v", got.names())
+	}
+}
+
+func TestCompressKeepsOriginal(t *testing.T) {
+	srcDir, distDir := t.TempDir(), t.TempDir()
+	// 低质量 JPEG 按 rate 1 重新编码时质量更高，文件更大
+	var optimized bytes.Buffer
+	jpeg.Encode(&optimized, randomNRGBA(64, 64, true), &jpeg.Options{Quality: 40})
+	os.WriteFile(filepath.Join(srcDir, "optimized.jpg"), optimized.Bytes(), 0644)
+	// 带 20KB 注释段的 JPEG，去掉注释后即为原图
+	var encoded, comment bytes.Buffer
+	jpeg.Encode(&encoded, randomNRGBA(64, 64, false), &jpeg.Options{Quality: 40})
+	text := bytes.Repeat([]byte("x"), 20000)
+	comment.Write(encoded.Bytes()[:2])
+	comment.Write([]byte{0xff, 0xfe, byte((len(text) + 2) >> 8), byte(len(text) + 2)})
+	comment.Write(text)
+	comment.Write(encoded.Bytes()[2:])
+	os.WriteFile(filepath.Join(srcDir, "comment.jpg"), comment.Bytes(), 0644)
+	// 带 GPS 的低质量 JPEG，移除元数据后的原文件比重新编码更小
+	gps, _ := embedJPEGMetadata(optimized.Bytes(), imageMetadata{EXIF: testEXIF(1)})
+	os.WriteFile(filepath.Join(srcDir, "gps.jpg"), gps, 0644)
+	// ImageMagick 把 EXIF 存在 zTXt 块中，readMetadata 识别不到，也必须移除
+	var plainPNG bytes.Buffer
+	png.Encode(&plainPNG, randomNRGBA(64, 64, false))
+	exifPNG := insertPNGChunk(plainPNG.Bytes(), "zTXt", []byte("Raw profile type exif\x00\x00GPSLatitude"))
+	os.WriteFile(filepath.Join(srcDir, "exif.png"), exifPNG, 0644)
+	// 未压缩的 PNG，重新编码节省足够多
+	var large bytes.Buffer
+	(&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&large, image.NewGray(image.Rect(0, 0, 128, 128)))
+	os.WriteFile(filepath.Join(srcDir, "large.png"), large.Bytes(), 0644)
+
+	var jobs []compressJob
+	for _, name := range []string{"comment.jpg", "exif.png", "gps.jpg", "large.png", "optimized.jpg"} {
+		jobs = append(jobs, compressJob{Src: filepath.Join(srcDir, name), Dist: filepath.Join(distDir, name), Rel: name})
+	}
+	opts := compressOptions{
+		Size:       sizeOptions{Rate: 1},
+		Filter:     resampleFilters[defaultResampleFilter],
+		Metadata:   metadataStrip,
+		MinSavings: defaultMinSavings,
+		Jobs:       2,
+		MaxMemory:  1 << 20,
+	}
+	var out bytes.Buffer
+	summary := runCompressJobs(jobs, opts, &out)
+
+	var kept []string
+	for _, k := range summary.Kept {
+		kept = append(kept, k.Rel)
+	}
+	if len(summary.Failures) != 0 || strings.Join(kept, ",") != "comment.jpg,exif.png,gps.jpg,optimized.jpg" {
+		t.Fatalf("应保留除 large.png 之外的原文件: %+v", summary)
+	}
+	// 原文件去掉注释、zTXt 和 EXIF 后写入
+	expected := map[string][]byte{
+		"optimized.jpg": optimized.Bytes(),
+		"gps.jpg":       optimized.Bytes(),
+		"comment.jpg":   encoded.Bytes(),
+		"exif.png":      plainPNG.Bytes(),
+	}
+	for name, want := range expected {
+		if data, _ := os.ReadFile(filepath.Join(distDir, name)); !bytes.Equal(data, want) {
+			t.Errorf("%s 应写入移除元数据后的原文件，实际为 %d 字节，期望 %d 字节", name, len(data), len(want))
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(distDir, "large.png")); len(data) >= large.Len() {
+		t.Errorf("large.png 应重新编码: %d >= %d", len(data), large.Len())
+	}
+	if summary.CompressedBytes > summary.OriginalBytes {
+		t.Errorf("输出总大小不应超过原文件: %d > %d", summary.CompressedBytes, summary.OriginalBytes)
+	}
+}
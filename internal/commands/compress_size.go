@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// 缩放模式（--fit）
+const (
+	fitContain = "contain" // 保持比例缩放到框内，并留白补齐到框的尺寸
+	fitCover   = "cover"   // 保持比例铺满整个框，居中裁掉超出部分
+	fitFill    = "fill"    // 拉伸到框的尺寸，不保持比例
+	fitInside  = "inside"  // 保持比例缩放到框内，不留白（默认）
+)
+
+// transparentBackground 支持透明的格式在 contain 模式下的留白颜色，JPEG 使用 --background
+var transparentBackground color.Color = color.Transparent
+
+// sizeOptions 输出尺寸选项
+//
+// 指定 Width 或 Height 时按 Fit 模式缩放到目标框（为 0 的边不限制）；否则按 Rate 等比缩放。
+type sizeOptions struct {
+	Rate    float64
+	Width   int
+	Height  int
+	Fit     string
+	Upscale bool // 是否允许放大，默认不放大
+}
+
+// hasBox 是否指定了目标框
+func (o sizeOptions) hasBox() bool {
+	return o.Width > 0 || o.Height > 0
+}
+
+// describe 描述尺寸选项，用于输出
+func (o sizeOptions) describe() string {
+	if !o.hasBox() {
+		return fmt.Sprintf("按比率 %.2f", o.Rate)
+	}
+	side := func(v int) string {
+		if v == 0 {
+			return "不限"
+		}
+		return fmt.Sprint(v)
+	}
+	text := fmt.Sprintf("%s（%s x %s）", o.Fit, side(o.Width), side(o.Height))
+	if o.Upscale {
+		text += "，允许放大"
+	}
+	return text
+}
+
+// newSizeOptions 根据命令行参数确定尺寸选项
+//
+// --max-width / --max-height 等价于 --width / --height --fit inside，两组参数不能混用；
+// 显式指定的 --rate 不能与尺寸参数同时使用。
+func newSizeOptions(rate float64, rateChanged bool, width, height, maxWidth, maxHeight int, fit string, upscale bool) (sizeOptions, error) {
+	opts := sizeOptions{Rate: rate, Width: width, Height: height, Fit: fit, Upscale: upscale}
+
+	for name, value := range map[string]int{"--width": width, "--height": height, "--max-width": maxWidth, "--max-height": maxHeight} {
+		if value < 0 {
+			return opts, fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	if maxWidth > 0 || maxHeight > 0 {
+		if width > 0 || height > 0 {
+			return opts, fmt.Errorf("--max-width/--max-height 不能与 --width/--height 同时使用")
+		}
+		if fit != "" && fit != fitInside {
+			return opts, fmt.Errorf("--max-width/--max-height 只能使用 --fit %s", fitInside)
+		}
+		opts.Width, opts.Height, opts.Fit = maxWidth, maxHeight, fitInside
+	}
+
+	switch opts.Fit {
+	case "":
+		opts.Fit = fitInside
+	case fitContain, fitCover, fitFill, fitInside:
+	default:
+		return opts, fmt.Errorf("未知的缩放模式: %s（可选 contain、cover、fill、inside）", fit)
+	}
+
+	if opts.hasBox() {
+		if rateChanged {
+			return opts, fmt.Errorf("--rate 不能与 --width/--height/--max-width/--max-height 同时使用")
+		}
+		if (opts.Fit == fitCover || opts.Fit == fitFill) && (opts.Width == 0 || opts.Height == 0) {
+			return opts, fmt.Errorf("--fit %s 需要同时指定 --width 和 --height", opts.Fit)
+		}
+	} else if fit != "" {
+		return opts, fmt.Errorf("--fit 需要配合 --width 或 --height 使用")
+	}
+	return opts, nil
+}
+
+// resizePlan 缩放方案
+type resizePlan struct {
+	Crop   image.Rectangle // 先裁剪的源图区域（相对源图左上角），为空表示不裁剪
+	Width  int             // 缩放后的宽度
+	Height int             // 缩放后的高度
+	Canvas image.Point     // 留白后的画布尺寸，为零表示不留白
+}
+
+// Size 返回最终输出尺寸
+func (p resizePlan) Size() image.Point {
+	if p.Canvas != (image.Point{}) {
+		return p.Canvas
+	}
+	return image.Point{X: p.Width, Y: p.Height}
+}
+
+// scaled 按比例缩小输出尺寸（含留白画布），裁剪区域不变
+func (p resizePlan) scaled(scale float64) resizePlan {
+	if scale == 1 {
+		return p
+	}
+	p.Width, p.Height = scaleSide(p.Width, scale), scaleSide(p.Height, scale)
+	if p.Canvas != (image.Point{}) {
+		p.Canvas = image.Point{X: scaleSide(p.Canvas.X, scale), Y: scaleSide(p.Canvas.Y, scale)}
+	}
+	return p
+}
+
+// planResize 根据源图尺寸和尺寸选项计算缩放方案，除 fill 外始终保持宽高比
+func planResize(srcWidth, srcHeight int, opts sizeOptions) resizePlan {
+	scaled := func(scale float64) (int, int) {
+		return scaleSide(srcWidth, scale), scaleSide(srcHeight, scale)
+	}
+
+	if !opts.hasBox() {
+		width, height := scaled(math.Min(opts.Rate, 1))
+		return resizePlan{Width: width, Height: height}
+	}
+
+	// 为 0 的边不限制
+	scaleX, scaleY := math.Inf(1), math.Inf(1)
+	if opts.Width > 0 {
+		scaleX = float64(opts.Width) / float64(srcWidth)
+	}
+	if opts.Height > 0 {
+		scaleY = float64(opts.Height) / float64(srcHeight)
+	}
+	limit := func(scale float64) float64 {
+		if !opts.Upscale && scale > 1 {
+			return 1
+		}
+		return scale
+	}
+
+	switch opts.Fit {
+	case fitFill:
+		width, height := opts.Width, opts.Height
+		if !opts.Upscale {
+			width, height = min(width, srcWidth), min(height, srcHeight)
+		}
+		return resizePlan{Width: width, Height: height}
+
+	case fitCover:
+		scale := limit(math.Max(scaleX, scaleY))
+		width := min(opts.Width, scaleSide(srcWidth, scale))
+		height := min(opts.Height, scaleSide(srcHeight, scale))
+		// 居中裁剪出与输出宽高比相同的源图区域
+		cropWidth := min(srcWidth, int(math.Round(float64(width)/scale)))
+		cropHeight := min(srcHeight, int(math.Round(float64(height)/scale)))
+		x, y := (srcWidth-cropWidth)/2, (srcHeight-cropHeight)/2
+		return resizePlan{Crop: image.Rect(x, y, x+cropWidth, y+cropHeight), Width: width, Height: height}
+	}
+
+	width, height := scaled(limit(math.Min(scaleX, scaleY)))
+	plan := resizePlan{Width: width, Height: height}
+	if opts.Fit == fitContain && opts.Width > 0 && opts.Height > 0 {
+		plan.Canvas = image.Point{X: opts.Width, Y: opts.Height}
+	}
+	return plan
+}
+
+// scaleSide 按比例缩放一条边，至少为 1 像素
+func scaleSide(size int, scale float64) int {
+	scaled := int(math.Round(float64(size) * scale))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
+
+// applyResizePlan 按方案裁剪、缩放并留白；background 为留白颜色
+func applyResizePlan(img image.Image, plan resizePlan, filter resampleFilter, background color.Color) image.Image {
+	if !plan.Crop.Empty() {
+		bounds := img.Bounds()
+		crop := plan.Crop.Add(bounds.Min)
+		if crop != bounds {
+			img = cropImage(img, crop)
+		}
+	}
+
+	img = resizeImage(img, plan.Width, plan.Height, filter)
+	if plan.Canvas == (image.Point{}) {
+		return img
+	}
+
+	canvas := image.NewRGBA(image.Rectangle{Max: plan.Canvas})
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	offset := image.Point{X: (plan.Canvas.X - plan.Width) / 2, Y: (plan.Canvas.Y - plan.Height) / 2}
+	bounds := img.Bounds()
+	draw.Draw(canvas, image.Rectangle{Min: offset, Max: offset.Add(bounds.Size())}, img, bounds.Min, draw.Over)
+	return canvas
+}
+
+// cropImage 裁剪图片，支持 SubImage 的类型直接共享像素
+func cropImage(img image.Image, rect image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	cropped := image.NewRGBA(image.Rectangle{Max: rect.Size()})
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+	return cropped
+}
@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestNewSizeOptions(t *testing.T) {
+	tests := []struct {
+		Name                               string
+		RateChanged                        bool
+		Width, Height, MaxWidth, MaxHeight int
+		Fit                                string
+		Want                               sizeOptions
+		WantErr                            bool
+	}{
+		{Name: "默认按比率", Want: sizeOptions{Rate: 0.8, Fit: fitInside}},
+		{Name: "最大宽度", MaxWidth: 1920, Want: sizeOptions{Rate: 0.8, Width: 1920, Fit: fitInside}},
+		{Name: "目标框", Width: 256, Height: 256, Fit: fitCover, Want: sizeOptions{Rate: 0.8, Width: 256, Height: 256, Fit: fitCover}},
+		{Name: "混用最大尺寸与目标框", Width: 100, MaxHeight: 100, WantErr: true},
+		{Name: "最大尺寸只能 inside", MaxWidth: 100, Fit: fitCover, WantErr: true},
+		{Name: "显式比率与目标框冲突", RateChanged: true, Width: 100, WantErr: true},
+		{Name: "cover 需要两边", Width: 100, Fit: fitCover, WantErr: true},
+		{Name: "fill 需要两边", Height: 100, Fit: fitFill, WantErr: true},
+		{Name: "fit 需要目标框", Fit: fitContain, WantErr: true},
+		{Name: "未知模式", Width: 100, Fit: "stretch", WantErr: true},
+		{Name: "负数", Width: -1, WantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := newSizeOptions(0.8, tt.RateChanged, tt.Width, tt.Height, tt.MaxWidth, tt.MaxHeight, tt.Fit, false)
+		if tt.WantErr {
+			if err == nil {
+				t.Errorf("%s: 期望报错，实际为 %+v", tt.Name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.Want {
+			t.Errorf("%s: 期望 %+v，实际为 %+v, %v", tt.Name, tt.Want, got, err)
+		}
+	}
+}
+
+func TestPlanResize(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Src     image.Point
+		Opts    sizeOptions
+		Want    image.Point
+		Crop    image.Rectangle
+		Resized image.Point
+	}{
+		{Name: "按比率", Src: image.Pt(1000, 500), Opts: sizeOptions{Rate: 0.5}, Want: image.Pt(500, 250)},
+		{Name: "只限宽度", Src: image.Pt(4000, 3000), Opts: sizeOptions{Width: 1920, Fit: fitInside}, Want: image.Pt(1920, 1440)},
+		{Name: "只限高度", Src: image.Pt(4000, 3000), Opts: sizeOptions{Height: 300, Fit: fitInside}, Want: image.Pt(400, 300)},
+		{Name: "inside 取较小比例", Src: image.Pt(4000, 1000), Opts: sizeOptions{Width: 800, Height: 800, Fit: fitInside}, Want: image.Pt(800, 200)},
+		{Name: "默认不放大", Src: image.Pt(640, 480), Opts: sizeOptions{Width: 1920, Fit: fitInside}, Want: image.Pt(640, 480)},
+		{Name: "允许放大", Src: image.Pt(640, 480), Opts: sizeOptions{Width: 1280, Fit: fitInside, Upscale: true}, Want: image.Pt(1280, 960)},
+		{Name: "contain 留白", Src: image.Pt(4000, 1000), Opts: sizeOptions{Width: 800, Height: 800, Fit: fitContain}, Want: image.Pt(800, 800), Resized: image.Pt(800, 200)},
+		{Name: "cover 居中裁剪", Src: image.Pt(4000, 1000), Opts: sizeOptions{Width: 800, Height: 800, Fit: fitCover}, Want: image.Pt(800, 800), Crop: image.Rect(1500, 0, 2500, 1000)},
+		{Name: "cover 不放大时裁剪到目标比例", Src: image.Pt(400, 100), Opts: sizeOptions{Width: 800, Height: 200, Fit: fitCover}, Want: image.Pt(400, 100), Crop: image.Rect(0, 0, 400, 100)},
+		{Name: "cover 小图只裁不放", Src: image.Pt(300, 200), Opts: sizeOptions{Width: 256, Height: 256, Fit: fitCover}, Want: image.Pt(256, 200), Crop: image.Rect(22, 0, 278, 200)},
+		{Name: "fill 拉伸", Src: image.Pt(1000, 500), Opts: sizeOptions{Width: 300, Height: 300, Fit: fitFill}, Want: image.Pt(300, 300)},
+		{Name: "fill 不放大", Src: image.Pt(200, 500), Opts: sizeOptions{Width: 300, Height: 300, Fit: fitFill}, Want: image.Pt(200, 300)},
+		{Name: "至少 1 像素", Src: image.Pt(10000, 10), Opts: sizeOptions{Width: 100, Fit: fitInside}, Want: image.Pt(100, 1)},
+	}
+
+	for _, tt := range tests {
+		plan := planResize(tt.Src.X, tt.Src.Y, tt.Opts)
+		if got := plan.Size(); got != tt.Want {
+			t.Errorf("%s: 期望输出 %v，实际为 %v", tt.Name, tt.Want, got)
+		}
+		if plan.Crop != tt.Crop {
+			t.Errorf("%s: 期望裁剪 %v，实际为 %v", tt.Name, tt.Crop, plan.Crop)
+		}
+		if tt.Resized != (image.Point{}) && image.Pt(plan.Width, plan.Height) != tt.Resized {
+			t.Errorf("%s: 期望缩放到 %v，实际为 %dx%d", tt.Name, tt.Resized, plan.Width, plan.Height)
+		}
+	}
+}
+
+func TestApplyResizePlanContain(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 10))
+	for i := range src.Pix {
+		src.Pix[i] = 255
+	}
+
+	plan := planResize(40, 10, sizeOptions{Width: 20, Height: 20, Fit: fitContain})
+	dst := applyResizePlan(src, plan, resampleFilters["box"], color.Black)
+	if got := dst.Bounds().Size(); got != image.Pt(20, 20) {
+		t.Fatalf("期望 20x20，实际为 %v", got)
+	}
+	for _, p := range []image.Point{{10, 0}, {10, 19}} {
+		if r, _, _, _ := dst.At(p.X, p.Y).RGBA(); r != 0 {
+			t.Errorf("%v 应为留白颜色", p)
+		}
+	}
+	if r, _, _, _ := dst.At(10, 10).RGBA(); r != 0xffff {
+		t.Error("中间应为原图内容")
+	}
+}
@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// stripMetadata 移除原文件中除解码所需之外的全部数据段，图像数据保持不变
+//
+// 按白名单保留数据段，未知的段（包括 PNG 的 tEXt、zTXt 中的 EXIF，JPEG 的注释和其他 APP 段）一律移除，
+// 文件尾部的附加数据也会丢弃。文件结构不完整或格式不支持（TIFF 的元数据是 IFD 标签）时返回 false。
+func stripMetadata(data []byte, format string) ([]byte, bool) {
+	switch format {
+	case "jpeg":
+		return stripJPEGMetadata(data)
+	case "png":
+		return stripPNGMetadata(data)
+	case "gif":
+		return stripGIFMetadata(data)
+	case "bmp":
+		// BMP 没有 EXIF、XMP 等元数据段
+		return data, true
+	}
+	return nil, false
+}
+
+// jpegKeptSegment 移除元数据时保留的 JPEG 段
+//
+// APP0（JFIF）、Adobe APP14（决定 CMYK 和 RGB 的颜色变换）、量化表、帧头、霍夫曼表、
+// 算术编码表、重新开始间隔和扫描头。
+func jpegKeptSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xe0, marker == 0xdb, marker == 0xdd, marker == 0xda:
+		return true
+	case marker == 0xee:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	}
+	// SOFn、DHT（0xc4）和 DAC（0xcc），0xc8 为保留标记
+	return marker >= 0xc0 && marker <= 0xcf && marker != 0xc8
+}
+
+// stripJPEGMetadata 按白名单保留 JPEG 段，到 EOI 为止
+func stripJPEGMetadata(data []byte) ([]byte, bool) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, false
+	}
+	output := make([]byte, 0, len(data))
+	output = append(output, 0xff, 0xd8)
+	scanned := false
+	for i := 2; i+2 <= len(data); {
+		if data[i] != 0xff {
+			return nil, false
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// 标记前可以有任意个 0xFF 填充字节
+			i++
+			continue
+		case marker == 0xd9:
+			if !scanned {
+				return nil, false
+			}
+			return append(output, 0xff, 0xd9), true
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd7:
+			// 不带长度的标记
+			output = append(output, data[i:i+2]...)
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, false
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return nil, false
+		}
+		if jpegKeptSegment(marker, data[i+4:end]) {
+			output = append(output, data[i:end]...)
+		}
+		i = end
+		if marker != 0xda {
+			continue
+		}
+
+		// 熵编码数据到下一个标记为止，0xFF00 是转义的 0xFF，RST 标记属于扫描数据
+		j := i
+		for j+1 < len(data) && !(data[j] == 0xff && data[j+1] != 0 && (data[j+1] < 0xd0 || data[j+1] > 0xd7)) {
+			j++
+		}
+		if j+1 >= len(data) {
+			return nil, false
+		}
+		output = append(output, data[i:j]...)
+		scanned = true
+		i = j
+	}
+	return nil, false
+}
+
+// pngKeptChunks 移除元数据时保留的 PNG 块：关键块和影响显示的透明度、伽马、sRGB、像素密度
+var pngKeptChunks = map[string]bool{
+	"IHDR": true,
+	"PLTE": true,
+	"IDAT": true,
+	"IEND": true,
+	"tRNS": true,
+	"gAMA": true,
+	"sRGB": true,
+	"pHYs": true,
+}
+
+// stripPNGMetadata 按白名单保留 PNG 块，到 IEND 为止
+func stripPNGMetadata(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, false
+	}
+	output := make([]byte, 0, len(data))
+	output = append(output, pngSignature...)
+	for i := len(pngSignature); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		kind := string(data[i+4 : i+8])
+		end := i + 8 + length + 4
+		if length < 0 || end > len(data) {
+			return nil, false
+		}
+		if pngKeptChunks[kind] {
+			output = append(output, data[i:end]...)
+		}
+		if kind == "IEND" {
+			return output, true
+		}
+		i = end
+	}
+	return nil, false
+}
+
+// gifLoopExtensions 记录循环次数的应用扩展
+var gifLoopExtensions = []string{"NETSCAPE2.0", "ANIMEXTS1.0"}
+
+// stripGIFMetadata 移除注释、纯文本和循环次数之外的应用扩展（如 XMP），到结尾标记为止
+func stripGIFMetadata(data []byte) ([]byte, bool) {
+	const headerSize = 13 // 签名 6 字节，逻辑屏幕描述 7 字节
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte("GIF8")) {
+		return nil, false
+	}
+	i := headerSize + gifColorTableSize(data[10])
+	if i > len(data) {
+		return nil, false
+	}
+	output := make([]byte, 0, len(data))
+	output = append(output, data[:i]...)
+	for i < len(data) {
+		switch data[i] {
+		case 0x3b:
+			return append(output, 0x3b), true
+		case 0x21:
+			// 扩展：标签后是若干数据子块
+			if i+2 > len(data) {
+				return nil, false
+			}
+			end, ok := gifSubBlocksEnd(data, i+2)
+			if !ok {
+				return nil, false
+			}
+			if gifKeptExtension(data[i+1], data[i+2:end]) {
+				output = append(output, data[i:end]...)
+			}
+			i = end
+		case 0x2c:
+			// 图像描述 10 字节、局部颜色表、LZW 最小码长和图像数据子块
+			if i+10 > len(data) {
+				return nil, false
+			}
+			start := i + 10 + gifColorTableSize(data[i+9]) + 1
+			if start > len(data) {
+				return nil, false
+			}
+			end, ok := gifSubBlocksEnd(data, start)
+			if !ok {
+				return nil, false
+			}
+			output = append(output, data[i:end]...)
+			i = end
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// gifColorTableSize 根据逻辑屏幕或图像描述的标志字节计算颜色表的字节数
+func gifColorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << ((flags & 0x07) + 1)
+}
+
+// gifSubBlocksEnd 返回从 start 开始的数据子块序列（以长度 0 结束）之后的位置
+func gifSubBlocksEnd(data []byte, start int) (int, bool) {
+	for i := start; i < len(data); {
+		size := int(data[i])
+		if size == 0 {
+			return i + 1, true
+		}
+		i += 1 + size
+	}
+	return 0, false
+}
+
+// gifKeptExtension 保留图形控制扩展（延迟、处置方式、透明色）和循环次数扩展
+func gifKeptExtension(label byte, blocks []byte) bool {
+	if label == 0xf9 {
+		return true
+	}
+	if label != 0xff || len(blocks) < 12 || blocks[0] != 11 {
+		return false
+	}
+	for _, id := range gifLoopExtensions {
+		if string(blocks[1:12]) == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// insertPNGChunk 在 IHDR 之后插入一个块
+func insertPNGChunk(data []byte, kind string, payload []byte) []byte {
+	const ihdrEnd = 33
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, payload...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	output := append([]byte{}, data[:ihdrEnd]...)
+	output = append(output, chunk...)
+	return append(output, data[ihdrEnd:]...)
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, randomNRGBA(8, 8, false))
+	data, _ := embedPNGMetadata(buf.Bytes(), imageMetadata{EXIF: testEXIF(1), XMP: []byte("<xmp/>"), ICC: []byte("profile")})
+	// ImageMagick 的 EXIF、tEXt 注释和 IEND 之后的附加数据
+	data = insertPNGChunk(data, "zTXt", []byte("Raw profile type exif\x00\x00GPS"))
+	data = insertPNGChunk(data, "tEXt", []byte("Comment\x00hello"))
+	data = append(data, "trailer"...)
+	if names := readMetadata(data, "png").names(); len(names) != 3 {
+		t.Fatalf("测试数据应带 3 项元数据: %v", names)
+	}
+
+	stripped, ok := stripMetadata(data, "png")
+	if !ok || !bytes.Equal(stripped, buf.Bytes()) {
+		t.Errorf("应还原为不带元数据的 PNG: %d 字节，期望 %d 字节", len(stripped), buf.Len())
+	}
+	// 没有 IEND 的文件不完整
+	if _, ok := stripMetadata(buf.Bytes()[:buf.Len()-12], "png"); ok {
+		t.Error("缺少 IEND 时应返回 false")
+	}
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, randomNRGBA(16, 16, false), &jpeg.Options{Quality: 80})
+	plain := buf.Bytes()
+
+	// 元数据、注释、未知 APP 段，标记前带填充字节，EOI 之后有附加数据
+	data, _ := embedJPEGMetadata(plain, imageMetadata{EXIF: testEXIF(1), ICC: []byte("profile"), IPTC: []byte("Photoshop 3.0\x00")})
+	var withExtras []byte
+	withExtras = append(withExtras, data[:2]...)
+	withExtras = append(withExtras, 0xff, 0xff, 0xfe, 0x00, 0x07, 'h', 'e', 'l', 'l', 'o')
+	withExtras = append(withExtras, 0xff, 0xeb, 0x00, 0x05, 'G', 'P', 'S')
+	withExtras = append(withExtras, data[2:]...)
+	withExtras = append(withExtras, "trailer"...)
+
+	stripped, ok := stripMetadata(withExtras, "jpeg")
+	if !ok || !bytes.Equal(stripped, plain) {
+		t.Fatalf("应还原为不带元数据的 JPEG: %d 字节，期望 %d 字节", len(stripped), len(plain))
+	}
+
+	// 没有到达 EOI 或在扫描之前遇到非标记字节时返回 false
+	for _, broken := range [][]byte{plain[:len(plain)-2], append([]byte{0xff, 0xd8, 0x00}, plain[2:]...)} {
+		if _, ok := stripMetadata(broken, "jpeg"); ok {
+			t.Error("不完整的 JPEG 应返回 false")
+		}
+	}
+}
+
+func TestStripGIFMetadata(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{LoopCount: 2, Delay: []int{10, 20}}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		frame.Pix[i] = 1
+		anim.Image = append(anim.Image, frame)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	plain := buf.Bytes()
+
+	// 在第一个图像块之前插入注释扩展和 XMP 应用扩展
+	first := bytes.Index(plain, []byte{0x21, 0xf9})
+	var data []byte
+	data = append(data, plain[:first]...)
+	data = append(data, 0x21, 0xfe, 0x03, 'G', 'P', 'S', 0x00)
+	data = append(data, 0x21, 0xff, 0x0b)
+	data = append(data, "XMP DataXMP"...)
+	data = append(data, 0x02, '<', '>', 0x00)
+	data = append(data, plain[first:]...)
+
+	stripped, ok := stripMetadata(data, "gif")
+	if !ok || !bytes.Equal(stripped, plain) {
+		t.Fatalf("应只移除注释和 XMP: %d 字节，期望 %d 字节", len(stripped), len(plain))
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(stripped))
+	if err != nil || len(decoded.Image) != 2 || decoded.LoopCount != 2 || decoded.Delay[1] != 20 {
+		t.Errorf("应保留帧、延迟和循环次数: %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+// 目标大小搜索的默认参数
+const (
+	defaultMinQuality = 40 // JPEG 最低质量
+	maxScaleSteps     = 8  // 尺寸二分的最多次数
+	minScaleStep      = 0.01
+)
+
+// byteSizePattern 文件大小，例如 300KB、1.5MB、1GB、2048
+var byteSizePattern = regexp.MustCompile("^" + config.ByteSizePattern + "$")
+
+// parseByteSize 解析文件大小，单位按 1024 进制
+func parseByteSize(text string) (int64, error) {
+	match := byteSizePattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return 0, fmt.Errorf("无效的文件大小: %s（例如 300KB、1.5MB）", text)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的文件大小: %s（例如 300KB、1.5MB）", text)
+	}
+
+	unit := strings.ToUpper(match[2])
+	switch {
+	case strings.HasPrefix(unit, "K"):
+		value *= 1 << 10
+	case strings.HasPrefix(unit, "M"):
+		value *= 1 << 20
+	case strings.HasPrefix(unit, "G"):
+		value *= 1 << 30
+	}
+	if value < 1 {
+		return 0, fmt.Errorf("文件大小必须大于 0: %s", text)
+	}
+	return int64(value), nil
+}
+
+// formatByteSize 格式化文件大小，用于输出
+func formatByteSize(size int64) string {
+	switch {
+	case size < 0:
+		return "-" + formatByteSize(-size)
+	case size >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// targetOptions 目标文件大小选项
+type targetOptions struct {
+	Size       int64   // 目标大小（字节），为 0 表示不限制
+	MinQuality int     // JPEG 可接受的最低质量
+	MinScale   float64 // 尺寸最多缩小到的比例，为 1 表示不缩小尺寸
+}
+
+// newTargetOptions 根据命令行参数确定目标大小选项
+func newTargetOptions(size string, minQuality int, minScale float64) (targetOptions, error) {
+	opts := targetOptions{MinQuality: minQuality, MinScale: minScale}
+	if size == "" {
+		if minQuality != defaultMinQuality || minScale != 1 {
+			return opts, fmt.Errorf("--min-quality 和 --min-scale 需要配合 --target-size 使用")
+		}
+		return opts, nil
+	}
+
+	limit, err := parseByteSize(size)
+	if err != nil {
+		return opts, err
+	}
+	opts.Size = limit
+	if minQuality < 1 || minQuality > 100 {
+		return opts, fmt.Errorf("--min-quality 必须在 1 到 100 之间")
+	}
+	if minScale <= 0 || minScale > 1 {
+		return opts, fmt.Errorf("--min-scale 必须大于 0 且不超过 1")
+	}
+	return opts, nil
+}
+
+// targetResult 满足目标大小的编码结果
+type targetResult struct {
+	Data    []byte
+	Quality int     // 选用的 JPEG 质量，无损格式为 0
+	Scale   float64 // 相对于计划尺寸的缩放比例
+}
+
+// describe 描述选用的质量和缩放，用于输出
+func (r targetResult) describe() string {
+	quality := "无损"
+	if r.Quality > 0 {
+		quality = strconv.Itoa(r.Quality)
+	}
+	return fmt.Sprintf("质量 %s，缩放 %.2f", quality, r.Scale)
+}
+
+// scaledEncoder 按缩放比例和质量编码图片
+type scaledEncoder func(scale float64, quality int) ([]byte, error)
+
+// fitTargetSize 搜索不超过目标大小的编码结果
+//
+// 先在计划尺寸下二分质量（minQuality 到 maxQuality），取满足目标的最高质量；
+// 最低质量仍超出且允许缩小尺寸时，以最低质量二分出满足目标的最大尺寸，再在该尺寸下二分质量。
+// 无损格式传入 minQuality == maxQuality，只搜索尺寸。
+func fitTargetSize(opts targetOptions, minQuality, maxQuality int, encode scaledEncoder) (targetResult, error) {
+	try := func(scale float64, quality int) (targetResult, bool, error) {
+		data, err := encode(scale, quality)
+		result := targetResult{Data: data, Quality: quality, Scale: scale}
+		return result, int64(len(data)) <= opts.Size, err
+	}
+
+	// bestQuality 在指定尺寸下取满足目标的最高质量
+	bestQuality := func(scale float64) (targetResult, bool, error) {
+		best, ok, err := try(scale, maxQuality)
+		if err != nil || ok || minQuality >= maxQuality {
+			return best, ok, err
+		}
+		smallest, ok, err := try(scale, minQuality)
+		if err != nil || !ok {
+			return smallest, ok, err
+		}
+
+		best = smallest
+		low, high := minQuality, maxQuality // low 满足目标，high 超出
+		for high-low > 1 {
+			middle := (low + high) / 2
+			result, ok, err := try(scale, middle)
+			if err != nil {
+				return result, false, err
+			}
+			if ok {
+				low, best = middle, result
+			} else {
+				high = middle
+			}
+		}
+		return best, true, nil
+	}
+
+	best, ok, err := bestQuality(1)
+	if err != nil || ok {
+		return best, err
+	}
+	if opts.MinScale >= 1 {
+		return best, targetSizeError(opts, best)
+	}
+
+	smallest, ok, err := try(opts.MinScale, minQuality)
+	if err != nil {
+		return smallest, err
+	}
+	if !ok {
+		return smallest, targetSizeError(opts, smallest)
+	}
+
+	low, high := opts.MinScale, 1.0 // low 满足目标，high 超出
+	for step := 0; step < maxScaleSteps && high-low > minScaleStep; step++ {
+		middle := (low + high) / 2
+		_, ok, err := try(middle, minQuality)
+		if err != nil {
+			return smallest, err
+		}
+		if ok {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+
+	best, _, err = bestQuality(low)
+	return best, err
+}
+
+// targetSizeError 无法满足目标大小时的错误
+func targetSizeError(opts targetOptions, smallest targetResult) error {
+	return fmt.Errorf("无法压缩到 %s 以内: %s 时仍有 %s（可降低 --min-quality 或设置 --min-scale）",
+		formatByteSize(opts.Size), smallest.describe(), formatByteSize(int64(len(smallest.Data))))
+}
+
+// compressToTarget 将图片压缩到目标大小以内
+//
+// JPEG 同时搜索质量和尺寸，最高质量与普通模式相同；其他格式为无损格式，只搜索尺寸。
+func compressToTarget(img image.Image, format string, plan resizePlan, opts compressOptions) (targetResult, error) {
+	var encode scaledEncoder
+	minQuality, maxQuality := 0, 0
+
+	switch compress, ok := imageEncoders[format]; {
+	case format == "jpeg":
+		maxQuality = jpegQuality(opts.Size.Rate)
+		minQuality = min(opts.Target.MinQuality, maxQuality)
+
+		// 同一尺寸只缩放一次，二分质量时复用
+		var resized image.Image
+		resizedScale := 0.0
+		encode = func(scale float64, quality int) ([]byte, error) {
+			if resized == nil || resizedScale != scale {
+				resized = applyResizePlan(img, plan.scaled(scale), opts.Filter, opts.Background)
+				resized, resizedScale = flattenAlpha(resized, opts.Background), scale
+			}
+			var buf bytes.Buffer
+			err := jpeg.Encode(&buf, resized, &jpeg.Options{Quality: quality})
+			return buf.Bytes(), err
+		}
+	case ok:
+		encode = func(scale float64, _ int) ([]byte, error) {
+			var buf bytes.Buffer
+			err := compress(img, &buf, plan.scaled(scale), opts)
+			return buf.Bytes(), err
+		}
+	default:
+		return targetResult{}, fmt.Errorf("格式 %s 不支持 --target-size", format)
+	}
+
+	return fitTargetSize(opts.Target, minQuality, maxQuality, encode)
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"300KB":  300 << 10,
+		"300 kb": 300 << 10,
+		"512K":   512 << 10,
+		"1.5MB":  3 << 19,
+		"2048":   2048,
+		"100B":   100,
+		"1GB":    1 << 30,
+	}
+	for text, want := range tests {
+		if got, err := parseByteSize(text); err != nil || got != want {
+			t.Errorf("%q: 期望 %d，实际为 %d, %v", text, want, got, err)
+		}
+	}
+
+	for _, text := range []string{"", "KB", "300TB", "-1KB", "0"} {
+		if _, err := parseByteSize(text); err == nil {
+			t.Errorf("%q 应报错", text)
+		}
+	}
+}
+
+func TestNewTargetOptions(t *testing.T) {
+	if opts, err := newTargetOptions("", defaultMinQuality, 1); err != nil || opts.Size != 0 {
+		t.Errorf("未指定目标大小时不应限制: %+v, %v", opts, err)
+	}
+	if _, err := newTargetOptions("", 60, 1); err == nil {
+		t.Error("--min-quality 单独使用应报错")
+	}
+	if _, err := newTargetOptions("300KB", 0, 1); err == nil {
+		t.Error("--min-quality 超出范围应报错")
+	}
+	if _, err := newTargetOptions("300KB", defaultMinQuality, 1.5); err == nil {
+		t.Error("--min-scale 超出范围应报错")
+	}
+}
+
+// fakeEncoder 输出大小与质量和面积成正比的编码器
+func fakeEncoder(calls *int) scaledEncoder {
+	return func(scale float64, quality int) ([]byte, error) {
+		*calls++
+		if quality == 0 {
+			quality = 100
+		}
+		return make([]byte, int(float64(quality*100)*scale*scale)), nil
+	}
+}
+
+func TestFitTargetSizeQuality(t *testing.T) {
+	calls := 0
+	result, err := fitTargetSize(targetOptions{Size: 6050, MinQuality: 40, MinScale: 1}, 40, 90, fakeEncoder(&calls))
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if result.Quality != 60 || result.Scale != 1 || len(result.Data) != 6000 {
+		t.Errorf("期望质量 60、缩放 1，实际为 %s", result.describe())
+	}
+	if calls > 10 {
+		t.Errorf("二分搜索次数过多: %d", calls)
+	}
+
+	// 最高质量已满足目标时直接使用
+	result, err = fitTargetSize(targetOptions{Size: 1 << 20, MinQuality: 40, MinScale: 1}, 40, 90, fakeEncoder(&calls))
+	if err != nil || result.Quality != 90 {
+		t.Errorf("期望质量 90，实际为 %+v, %v", result.Quality, err)
+	}
+}
+
+func TestFitTargetSizeScale(t *testing.T) {
+	calls := 0
+	// 质量 40 时需要缩小到约 0.5 才能满足目标
+	result, err := fitTargetSize(targetOptions{Size: 1000, MinQuality: 40, MinScale: 0.25}, 40, 90, fakeEncoder(&calls))
+	if err != nil {
+		t.Fatalf("搜索失败: %v", err)
+	}
+	if len(result.Data) > 1000 || result.Scale < 0.48 || result.Scale > 0.5 {
+		t.Errorf("期望缩放约 0.5，实际为 %s，%d 字节", result.describe(), len(result.Data))
+	}
+
+	// 无损格式只搜索尺寸
+	result, err = fitTargetSize(targetOptions{Size: 2500, MinScale: 0.1}, 0, 0, fakeEncoder(&calls))
+	if err != nil || result.Quality != 0 || result.Scale > 0.5 || result.Scale < 0.48 {
+		t.Errorf("期望缩放约 0.5，实际为 %+v, %v", result.Scale, err)
+	}
+}
+
+func TestFitTargetSizeFails(t *testing.T) {
+	calls := 0
+	_, err := fitTargetSize(targetOptions{Size: 1000, MinQuality: 40, MinScale: 1}, 40, 90, fakeEncoder(&calls))
+	if err == nil || !strings.Contains(err.Error(), "质量 40，缩放 1.00") {
+		t.Errorf("不允许缩小尺寸时应报错，实际为 %v", err)
+	}
+
+	_, err = fitTargetSize(targetOptions{Size: 100, MinQuality: 40, MinScale: 0.5}, 40, 90, fakeEncoder(&calls))
+	if err == nil || !strings.Contains(err.Error(), "缩放 0.50") {
+		t.Errorf("最小尺寸仍超出时应报错，实际为 %v", err)
+	}
+}
+
+func TestCompressToTargetJPEG(t *testing.T) {
+	img := randomNRGBA(200, 150, true)
+	plan := planResize(200, 150, sizeOptions{Rate: 1})
+	opts := compressOptions{
+		Size:   sizeOptions{Rate: 1},
+		Filter: resampleFilters[defaultResampleFilter],
+		Target: targetOptions{Size: 20 << 10, MinQuality: 10, MinScale: 0.3},
+	}
+
+	result, err := compressToTarget(img, "jpeg", plan, opts)
+	if err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	if int64(len(result.Data)) > opts.Target.Size {
+		t.Errorf("输出 %d 字节超出目标", len(result.Data))
+	}
+	if size := plan.scaled(result.Scale).Size(); size.X > 200 || size != image.Pt(scaleSide(200, result.Scale), scaleSide(150, result.Scale)) {
+		t.Errorf("输出尺寸不正确: %v", size)
+	}
+}
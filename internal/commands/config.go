@@ -0,0 +1,677 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigCommand 创建 config 命令组
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "管理配置文件",
+		Long: `管理 Cyber Zen Tools 的配置文件。
+
+配置文件:
+  file-types.yaml        文件类型
+  categories.yaml        文件分类
+  commit-templates.yaml  Commit 模板
+  .cyber-zen.yaml        仓库 / 目录级覆盖
+  config.yaml            应用设置（安装目录、团队名单、受保护分支等）
+
+作用域:
+  user  用户配置（~/.cyber-zen/configs 与安装目录下的 config.yaml）
+  repo  仓库根目录的 .cyber-zen.yaml`,
+	}
+
+	cmd.AddCommand(newConfigListCommand())
+	cmd.AddCommand(newConfigGetCommand())
+	cmd.AddCommand(newConfigSetCommand())
+	cmd.AddCommand(newConfigEditCommand())
+	cmd.AddCommand(newConfigWhereCommand())
+	cmd.AddCommand(newConfigExplainCommand())
+	cmd.AddCommand(newConfigImportLinguistCommand())
+	cmd.AddCommand(newConfigSchemaCommand())
+	cmd.AddCommand(newConfigMigrateCommand())
+	cmd.AddCommand(newConfigValidateCommand())
+	cmd.AddCommand(newConfigInitCommand())
+
+	return cmd
+}
+
+// newConfigValidateCommand 创建 config validate 命令
+func newConfigValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [file...]",
+		Short: "校验配置文件",
+		Long: `严格校验配置文件，报告未知字段、类型错误和非法取值，并给出文件和行号。
+
+校验规则与 config schema 输出的 JSON Schema 相同。
+不指定文件时校验 config.yaml、当前目录生效的所有配置层（内置、用户、配置档案、仓库）以及合并后的结果。
+
+示例:
+  cyber-zen config validate
+  cyber-zen config validate configs/categories.yaml .cyber-zen.yaml`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidate(args)
+		},
+	}
+
+	return cmd
+}
+
+// runConfigValidate 执行配置校验
+func runConfigValidate(files []string) error {
+	var issues []config.ValidationIssue
+
+	if len(files) > 0 {
+		for _, file := range files {
+			fileIssues := config.ValidateConfigFile(file)
+			printValidationResult(file, fileIssues)
+			issues = append(issues, fileIssues...)
+		}
+	} else {
+		// 应用设置 config.yaml 不属于配置层，单独校验
+		if settingsFile := config.GetSettingsFile(); settingsFile != "" {
+			if _, err := os.Stat(settingsFile); err == nil {
+				settingsIssues := config.ValidateConfigFile(settingsFile)
+				printValidationResult(fmt.Sprintf("[%s] %s", config.ScopeUser, settingsFile), settingsIssues)
+				issues = append(issues, settingsIssues...)
+			}
+		}
+
+		layers, allIssues, err := config.ValidateEffectiveConfig(".")
+		if err != nil {
+			return err
+		}
+		for _, layer := range layers {
+			var layerIssues []config.ValidationIssue
+			for _, issue := range allIssues {
+				if issue.File == layer.Path {
+					layerIssues = append(layerIssues, issue)
+				}
+			}
+			printValidationResult(fmt.Sprintf("[%s] %s", layer.Scope, layer.Path), layerIssues)
+		}
+		// 合并结果的问题没有对应文件
+		for _, issue := range allIssues {
+			if !issueInLayers(issue, layers) {
+				color.Red("  ✗ %s", issue.String())
+			}
+		}
+		issues = append(issues, allIssues...)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("发现 %d 个配置问题", len(issues))
+	}
+	color.Green("✓ 配置校验通过")
+	return nil
+}
+
+// printValidationResult 输出单个文件的校验结果
+func printValidationResult(name string, issues []config.ValidationIssue) {
+	if len(issues) == 0 {
+		color.Green("✓ %s", name)
+		return
+	}
+	color.Red("✗ %s", name)
+	for _, issue := range issues {
+		color.Red("  %s", issue.String())
+	}
+}
+
+// issueInLayers 问题是否属于某个配置层文件
+func issueInLayers(issue config.ValidationIssue, layers []config.ConfigLayer) bool {
+	for _, layer := range layers {
+		if issue.File == layer.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// newConfigInitCommand 创建 config init 命令
+func newConfigInitCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "将内置默认配置写入用户目录",
+		Long: `将编译进程序的默认配置写入 ~/.cyber-zen/configs，便于自定义。
+
+已存在的文件默认跳过，使用 --force 覆盖。
+
+示例:
+  cyber-zen config init
+  cyber-zen config init --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigInit(force)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "覆盖已存在的配置文件")
+
+	return cmd
+}
+
+// runConfigInit 执行配置初始化
+func runConfigInit(force bool) error {
+	dir := config.GetUserConfigDir()
+	if dir == "" {
+		return fmt.Errorf("无法确定用户配置目录")
+	}
+
+	written, skipped, err := config.WriteDefaultConfigs(dir, force)
+	for _, path := range written {
+		color.Green("✓ 写入: %s", path)
+	}
+	for _, path := range skipped {
+		color.Yellow("- 跳过（已存在，使用 --force 覆盖）: %s", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	color.Green("✓ 配置初始化完成: %s", dir)
+	return nil
+}
+
+// newConfigListCommand 创建 config list 命令
+func newConfigListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "列出所有生效的配置项",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := config.EffectiveValues(".")
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				fmt.Printf("%s = %s\n", value.Key, formatConfigValue(value.Value))
+			}
+			return nil
+		},
+	}
+}
+
+// newConfigGetCommand 创建 config get 命令
+func newConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "获取配置项的生效值",
+		Long: `获取配置项的生效值，key 使用点分隔的路径。
+
+示例:
+  cyber-zen config get compress.rate
+  cyber-zen config get categories.directory_patterns.test`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := config.GetValue(".", args[0])
+			if err != nil {
+				return err
+			}
+			switch value.Value.(type) {
+			case map[string]interface{}, []interface{}:
+				data, err := yaml.Marshal(value.Value)
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(data))
+			default:
+				fmt.Println(formatConfigValue(value.Value))
+			}
+			return nil
+		},
+	}
+}
+
+// newConfigSetCommand 创建 config set 命令
+func newConfigSetCommand() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "设置配置项",
+		Long: `将配置项写入 user 或 repo 作用域的配置文件，值按 YAML 解析，文件中的注释会被保留。
+
+示例:
+  cyber-zen config set compress.rate 0.6
+  cyber-zen config set protected_branches "[main, release/*]" --scope repo
+  cyber-zen config set categories.default 功能模块 --scope repo`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := config.SetValue(".", args[0], args[1], scope)
+			if err != nil {
+				return err
+			}
+			color.Green("✓ 已设置 %s = %s (%s)", args[0], args[1], path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scope, "scope", config.ScopeUser, "写入的作用域: user 或 repo")
+
+	return cmd
+}
+
+// newConfigEditCommand 创建 config edit 命令
+func newConfigEditCommand() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "edit [key]",
+		Short: "使用 $EDITOR 编辑配置文件",
+		Long: `在 $VISUAL / $EDITOR（默认 vi）中打开配置文件，保存后自动校验。
+
+指定 key 时打开该作用域中保存此 key 的文件；否则打开作用域的主配置文件
+（user: config.yaml，repo: .cyber-zen.yaml）。
+
+示例:
+  cyber-zen config edit
+  cyber-zen config edit categories --scope repo`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := ""
+			if len(args) > 0 {
+				key = args[0]
+			}
+			return runConfigEdit(key, scope)
+		},
+	}
+
+	cmd.Flags().StringVar(&scope, "scope", config.ScopeUser, "编辑的作用域: user 或 repo")
+
+	return cmd
+}
+
+// runConfigEdit 在编辑器中打开配置文件
+func runConfigEdit(key, scope string) error {
+	path, err := config.ResolveConfigFile(".", key, scope)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("创建配置目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			return fmt.Errorf("创建配置文件失败: %v", err)
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// EDITOR 可能包含参数，例如 "code --wait"
+	parts := strings.Fields(editor)
+	editCmd := exec.Command(parts[0], append(parts[1:], path)...)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	if err := editCmd.Run(); err != nil {
+		return fmt.Errorf("编辑器退出失败: %v", err)
+	}
+
+	if issues := config.ValidateConfigFile(path); len(issues) > 0 {
+		printValidationResult(path, issues)
+		return fmt.Errorf("发现 %d 个配置问题，请重新编辑", len(issues))
+	}
+	color.Green("✓ 已保存: %s", path)
+	return nil
+}
+
+// newConfigWhereCommand 创建 config where 命令
+func newConfigWhereCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "where [key-prefix]",
+		Short: "显示每个配置项的来源文件",
+		Long: `显示每个生效配置项来自哪个文件（或默认值），可按键前缀过滤。
+
+示例:
+  cyber-zen config where
+  cyber-zen config where categories.default`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := config.EffectiveValues(".")
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				if len(args) > 0 && value.Key != args[0] && !strings.HasPrefix(value.Key, args[0]+".") {
+					continue
+				}
+				source := value.Source
+				if source == "" {
+					source = "-"
+				}
+				fmt.Printf("%s\t[%s] %s\n", value.Key, value.Scope, source)
+			}
+			return nil
+		},
+	}
+}
+
+// newConfigExplainCommand 创建 config explain 命令
+func newConfigExplainCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <path>",
+		Short: "说明文件命中了哪条分类规则",
+		Long: `说明文件路径命中了哪条分类规则、哪个模式，以及被覆盖的其他候选规则。
+
+路径相对于当前目录，会被转换为相对仓库根目录的路径后再匹配。
+
+示例:
+  cyber-zen config explain internal/foo/contest.go
+  cyber-zen config explain web/src/App.test.tsx`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigExplain(args[0])
+		},
+	}
+}
+
+// runConfigExplain 输出文件分类的匹配过程
+func runConfigExplain(path string) error {
+	// 与 gcm 一致，使用文件所在目录生效的配置
+	fileTypeManager, err := config.NewFileTypeManagerForDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	explanation := fileTypeManager.ExplainFileCategory(repoRelativePath(path))
+	fmt.Printf("路径: %s\n", explanation.Path)
+	fmt.Printf("类型: %s\n", fileTypeManager.GetFileType(path))
+
+	if explanation.Matched == nil {
+		color.Yellow("分类: %s（未命中任何规则，使用默认分类）", explanation.Default)
+		return nil
+	}
+
+	match := explanation.Matched
+	color.Green("分类: %s", match.Description)
+	fmt.Printf("  规则: categories.directory_patterns.%s (priority %d, order %d)\n", match.Category, match.Priority, match.Order)
+	fmt.Printf("  模式: %q（glob %s）\n", match.Pattern, match.Glob)
+
+	if len(explanation.Others) > 0 {
+		fmt.Println("被覆盖的候选规则:")
+		for _, other := range explanation.Others {
+			color.HiBlack("  - %s: %s 模式 %q (priority %d, order %d)", other.Category, other.Description, other.Pattern, other.Priority, other.Order)
+		}
+	}
+	return nil
+}
+
+// repoRelativePath 将路径转换为相对仓库根目录的路径，不在仓库中时原样返回
+func repoRelativePath(path string) string {
+	root := config.FindRepoRoot(".")
+	if root == "" {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// newConfigImportLinguistCommand 创建 config import-linguist 命令
+func newConfigImportLinguistCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "import-linguist <languages.yml>",
+		Short: "从 GitHub Linguist 导入文件类型",
+		Long: `将 GitHub Linguist 的 languages.yml 转换为文件类型配置（扩展名、文件名、解释器），
+按语言类型归入 frontend、backend、data、docs、build 等分类。
+
+导入的条目带有 source: linguist 标记，重新导入时会整体替换；手写条目始终保留，
+且手写条目占用的扩展名、文件名和解释器不会被重复导入，识别时手写条目优先。
+
+默认写入 ~/.cyber-zen/configs/file-types.yaml。
+
+示例:
+  curl -fsSLO https://raw.githubusercontent.com/github-linguist/linguist/main/lib/linguist/languages.yml
+  cyber-zen config import-linguist languages.yml`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				dir := config.GetUserConfigDir()
+				if dir == "" {
+					return fmt.Errorf("无法确定用户配置目录")
+				}
+				output = filepath.Join(dir, "file-types.yaml")
+			}
+			return runConfigImportLinguist(args[0], output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "写入的文件（默认 ~/.cyber-zen/configs/file-types.yaml）")
+
+	return cmd
+}
+
+// runConfigImportLinguist 执行 Linguist 导入
+func runConfigImportLinguist(languagesPath, output string) error {
+	result, err := config.ImportLinguist(".", languagesPath, output)
+	if err != nil {
+		return err
+	}
+
+	if result.Removed > 0 {
+		fmt.Printf("移除上次导入的 %d 个条目\n", result.Removed)
+	}
+	if len(result.Skipped) > 0 {
+		color.Yellow("- 跳过 %d 个语言（与手写配置重名或规则已被占用）", len(result.Skipped))
+	}
+	color.Green("✓ 导入 %d 个语言: %s", result.Imported, result.Path)
+	return nil
+}
+
+// newConfigSchemaCommand 创建 config schema 命令
+func newConfigSchemaCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "schema [file...]",
+		Short: "输出配置文件的 JSON Schema",
+		Long: `输出配置文件的 JSON Schema，供编辑器自动补全和校验。
+
+Schema 由程序中的配置结构生成，与 config validate 使用的规则一致。
+可选文件: config.yaml、file-types.yaml、categories.yaml、commit-templates.yaml、.cyber-zen.yaml
+（也可以省略 .yaml 和前导 .，例如 cyber-zen）。
+
+不指定 --output 时输出到标准输出；指定目录时每个文件写入 <名称>.schema.json，
+未指定文件则写入全部 Schema。
+
+示例:
+  cyber-zen config schema file-types > file-types.schema.json
+  cyber-zen config schema --output .vscode/schemas`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigSchema(args, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "写入 Schema 的目录")
+
+	return cmd
+}
+
+// runConfigSchema 生成并输出 JSON Schema
+func runConfigSchema(names []string, output string) error {
+	if len(names) == 0 {
+		if output == "" {
+			fmt.Println("可用的配置文件:")
+			for _, name := range config.ConfigSchemaNames() {
+				fmt.Printf("  %s\n", name)
+			}
+			fmt.Println("\n使用 cyber-zen config schema <文件> 输出 Schema，或 --output <目录> 写入全部 Schema")
+			return nil
+		}
+		names = config.ConfigSchemaNames()
+	}
+
+	if output == "" {
+		if len(names) > 1 {
+			return fmt.Errorf("输出多个 Schema 时请使用 --output 指定目录")
+		}
+		data, err := marshalConfigSchema(names[0])
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	for _, name := range names {
+		data, err := marshalConfigSchema(name)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(output, config.SchemaFileName(filepath.Base(name)))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("写入 Schema 失败: %v", err)
+		}
+		color.Green("✓ %s", path)
+	}
+	return nil
+}
+
+// marshalConfigSchema 生成配置文件的 Schema 并编码为带缩进的 JSON
+func marshalConfigSchema(name string) ([]byte, error) {
+	schema, err := config.ConfigSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("编码 Schema 失败: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// newConfigMigrateCommand 创建 config migrate 命令
+func newConfigMigrateCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate [file...]",
+		Short: "将配置文件升级到当前格式版本",
+		Long: fmt.Sprintf(`将旧版本格式的配置文件升级到当前版本（version %d），并写入 version 字段。
+
+旧版本配置在加载时会自动在内存中升级并给出警告，此命令将升级结果写回文件。
+写入前原文件备份为 <文件>.v<原版本>.bak，文件中的注释会被保留。
+
+不指定文件时处理 config.yaml、系统和用户配置目录中的配置文件，以及当前目录生效的 .cyber-zen.yaml。
+
+示例:
+  cyber-zen config migrate --dry-run
+  cyber-zen config migrate .cyber-zen.yaml`, config.CurrentConfigVersion),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigMigrate(args, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只显示将要进行的修改，不写入文件")
+
+	return cmd
+}
+
+// runConfigMigrate 执行配置迁移
+func runConfigMigrate(files []string, dryRun bool) error {
+	if len(files) == 0 {
+		files = config.MigrationTargets(".")
+	}
+	if len(files) == 0 {
+		fmt.Println("没有需要迁移的配置文件")
+		return nil
+	}
+
+	migrated := 0
+	for _, file := range files {
+		result, err := config.MigrateConfigFile(file, dryRun)
+		if err != nil {
+			return err
+		}
+		if !result.Migrated() {
+			color.Green("✓ 已是最新版本（version %d）: %s", result.To, file)
+			continue
+		}
+
+		migrated++
+		if dryRun {
+			color.Yellow("将升级 %s: version %d -> %d", file, result.From, result.To)
+		} else {
+			color.Green("✓ 已升级 %s: version %d -> %d（备份: %s）", file, result.From, result.To, result.Backup)
+		}
+		for _, change := range result.Changes {
+			fmt.Printf("  - %s\n", change)
+		}
+	}
+
+	if dryRun && migrated > 0 {
+		fmt.Printf("\n%d 个文件需要升级，去掉 --dry-run 后写入\n", migrated)
+	}
+	return nil
+}
+
+// formatConfigValue 将配置值格式化为单行文本
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatConfigValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, fmt.Sprintf("%s: %s", key, formatConfigValue(v[key])))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+func TestInvalidSettingsOnlyWarn(t *testing.T) {
+	installDir := t.TempDir()
+	t.Setenv("CYBER_ZEN_INSTALL_DIR", installDir)
+
+	// 在仓库之外运行，避免读取本仓库的 .cyber-zen.yaml
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取当前目录失败: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("切换到临时目录失败: %v", err)
+	}
+	originalHandler := config.WarningHandler
+	defer func() {
+		_ = os.Chdir(originalDir)
+		config.GlobalConfig = nil
+		config.WarningHandler = originalHandler
+	}()
+
+	run := func(args ...string) ([]string, error) {
+		cmd := NewRootCommand()
+		var warnings []string
+		config.WarningHandler = func(message string) { warnings = append(warnings, message) }
+		cmd.SetArgs(args)
+		cmd.SilenceErrors = true
+		return warnings, cmd.Execute()
+	}
+
+	tests := []struct {
+		name     string
+		settings string
+		warning  string
+	}{
+		{"未知字段", "compres:\n  rate: 0.5\n", "compres"},
+		{"不支持的语言", "language: fr\n", "fr"},
+		{"档案中的未知字段", "profile: oss\nprofiles:\n  oss:\n    compres:\n      rate: 0.5\n", "profiles.oss"},
+		{"语法错误", "compress: [a\n", "line 1"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(installDir, "config.yaml"), []byte(tt.settings), 0644); err != nil {
+			t.Fatalf("写入配置失败: %v", err)
+		}
+
+		// config 子命令总能运行，用于查看和修复配置
+		commands := [][]string{{"config", "where", "language"}}
+		if tt.name != "语法错误" {
+			// 其他命令只输出警告，无效的设置回退到默认值
+			commands = append(commands, []string{"status"}, []string{"config", "get", "compress.rate"})
+		}
+		for _, args := range commands {
+			warnings, err := run(args...)
+			if err != nil {
+				t.Errorf("%s: %v 不应失败: %v", tt.name, args, err)
+			}
+			if !strings.Contains(strings.Join(warnings, "\n"), tt.warning) {
+				t.Errorf("%s: %v 应警告 %s，实际为 %v", tt.name, args, tt.warning, warnings)
+			}
+		}
+		if tt.name == "不支持的语言" && config.GetLanguage() != "zh" {
+			t.Errorf("不支持的语言应回退到 zh，实际为 %s", config.GetLanguage())
+		}
+
+		// config validate 报告问题而不是初始化失败
+		_, err := run("config", "validate")
+		if err == nil || strings.Contains(err.Error(), "配置初始化失败") {
+			t.Errorf("%s: config validate 应报告配置问题，实际为 %v", tt.name, err)
+		}
+	}
+}
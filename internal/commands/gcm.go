@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
@@ -11,8 +13,18 @@ import (
 	"github.com/your-repo/cyben-zen-tools/internal/config"
 )
 
+// gcmOptions gcm 命令选项
+type gcmOptions struct {
+	With           []string // 本次提交追加的共同作者别名
+	AllowProtected bool     // 允许提交到受保护分支
+	Recurse        bool     // 先提交并推送有修改的子模块
+	NoPush         bool     // 只提交不推送
+}
+
 // newGcmCommand 创建 gcm 命令
 func newGcmCommand() *cobra.Command {
+	var opts gcmOptions
+
 	cmd := &cobra.Command{
 		Use:   "gcm [message]",
 		Short: "Git 提交并推送",
@@ -21,28 +33,98 @@ func newGcmCommand() *cobra.Command {
   2. git commit -m "message" --no-verify
   3. git push
 
-如果没有提供提交信息，将自动分析变更并生成智能的 commit message`,
+提交前会检查未解决的冲突、残留的冲突标记以及进行中的
+merge / rebase / cherry-pick / revert / bisect 操作。
+冲突已解决的 merge 会使用合并提交信息直接完成。
+配置中 protected_branches 列出的分支默认拒绝直接提交。
+
+子模块：
+  子模块指针变化会显示新旧提交及其间的日志，只有子模块变化时
+  生成 chore(deps): bump <submodule> 形式的提交信息；
+  --recurse-submodules 会先进入有修改的子模块提交并推送
+
+如果没有提供提交信息，将自动分析变更并生成智能的 commit message
+
+共同作者：
+  --with alice,bob 追加 Co-authored-by 署名（成员来自配置中的 team 名单）
+  使用 cyber-zen pair 设置的结对成员会自动追加到每次提交
+
+默认值（可在配置、CYBER_ZEN_GCM_* 环境变量或全局标志中设置）：
+  gcm.push                 提交后是否推送（默认 true）
+  gcm.recurse_submodules   是否默认 --recurse-submodules
+  gcm.with                 每次提交默认追加的共同作者`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGcm(args)
+			// 未显式指定时使用配置中的默认值
+			defaults := config.GetGcmConfig()
+			if !cmd.Flags().Changed("recurse-submodules") {
+				opts.Recurse = defaults.RecurseSubmodules
+			}
+			if !cmd.Flags().Changed("no-push") {
+				opts.NoPush = !defaults.Push
+			}
+			opts.With = append(append([]string{}, defaults.With...), opts.With...)
+			return runGcm(args, opts)
 		},
 	}
 
+	cmd.Flags().StringSliceVar(&opts.With, "with", nil, "追加 Co-authored-by 署名的成员别名（逗号分隔）")
+	cmd.Flags().BoolVar(&opts.AllowProtected, "allow-protected", false, "允许直接提交到受保护分支")
+	cmd.Flags().BoolVar(&opts.Recurse, "recurse-submodules", false, "先在有修改的子模块中执行 gcm")
+	cmd.Flags().BoolVar(&opts.NoPush, "no-push", false, "只提交，不执行 git push")
+
 	return cmd
 }
 
 // runGcm 执行 Git 提交和推送
-func runGcm(args []string) error {
+func runGcm(args []string, opts gcmOptions) error {
 	var msg string
-	
+
+	// 检查是否在 Git 仓库中
+	if err := checkGitRepo(); err != nil {
+		return err
+	}
+
+	// 检查冲突和进行中的 Git 操作
+	state, err := inspectRepoState()
+	if err != nil {
+		return err
+	}
+	if err := state.blockingError(); err != nil {
+		return err
+	}
+
+	// 检查受保护分支
+	if branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD"); err == nil && config.IsProtectedBranch(branch) {
+		if !opts.AllowProtected {
+			return fmt.Errorf("分支 %s 受保护，gcm 不会直接提交；请切换到新分支，或使用 --allow-protected", branch)
+		}
+		color.Yellow("⚠️  正在提交到受保护分支: %s", branch)
+	}
+
+	// 先提交有修改的子模块，使父仓库记录新的子模块指针
+	if opts.Recurse {
+		if err := commitDirtySubmodules(opts); err != nil {
+			return err
+		}
+	}
+
 	if len(args) > 0 {
 		// 用户提供了 message，直接使用
 		msg = args[0]
 		color.Cyan("使用用户提供的提交信息: %s", msg)
+	} else if state.isMerging() {
+		// 冲突已解决的合并，使用合并提交信息完成合并
+		msg = generateMergeMessage()
+		color.Yellow("检测到进行中的合并，将完成合并提交")
 	} else {
 		// 用户没有提供 message，自动生成
 		color.Yellow("未提供提交信息，正在自动分析变更...")
-		var err error
 		msg, err = generateCommitMessage()
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			// 配置错误需要修复，不能静默回退
+			return err
+		}
 		if err != nil {
 			color.Red("自动生成失败: %v", err)
 			color.Yellow("使用默认提交信息: update")
@@ -52,13 +134,15 @@ func runGcm(args []string) error {
 		}
 	}
 
-	color.Green("开始执行 Git 操作...")
-	color.Cyan("提交信息: %s", msg)
-
-	// 检查是否在 Git 仓库中
-	if err := checkGitRepo(); err != nil {
+	// 追加共同作者署名
+	coAuthors, err := collectCoAuthors(opts.With)
+	if err != nil {
 		return err
 	}
+	msg = appendCoAuthorTrailers(msg, coAuthors)
+
+	color.Green("开始执行 Git 操作...")
+	color.Cyan("提交信息: %s", msg)
 
 	// 执行 git add .
 	color.Yellow("执行: git add .")
@@ -67,6 +151,15 @@ func runGcm(args []string) error {
 	}
 	color.Green("✓ git add . 完成")
 
+	// 提交前检查暂存文件中是否残留冲突标记
+	state.Markers, err = findStagedConflictMarkers()
+	if err != nil {
+		return err
+	}
+	if err := state.blockingError(); err != nil {
+		return err
+	}
+
 	// 执行 git commit
 	color.Yellow("执行: git commit -m \"%s\" --no-verify", msg)
 	if err := execGitCommand("commit", "-m", msg, "--no-verify"); err != nil {
@@ -74,6 +167,18 @@ func runGcm(args []string) error {
 	}
 	color.Green("✓ git commit 完成")
 
+	// 记录到 gcm 日志，供 undo 使用
+	entry, err := recordGcmCommit(msg)
+	if err != nil {
+		color.Yellow("⚠️  记录 gcm 日志失败: %v", err)
+	}
+
+	if opts.NoPush {
+		color.Yellow("跳过 git push")
+		color.Green("🎉 Git 操作完成！")
+		return nil
+	}
+
 	// 执行 git push
 	color.Yellow("执行: git push")
 	if err := execGitCommand("push"); err != nil {
@@ -81,16 +186,23 @@ func runGcm(args []string) error {
 	}
 	color.Green("✓ git push 完成")
 
+	if entry != nil {
+		if err := markGcmPushed(entry.SHA); err != nil {
+			color.Yellow("⚠️  更新 gcm 日志失败: %v", err)
+		}
+	}
+
 	color.Green("🎉 Git 操作完成！")
 	return nil
 }
 
 // ChangeInfo 变更信息结构
 type ChangeInfo struct {
-	File     string
-	Status   string
-	Category string
-	Type     string
+	File      string
+	Status    string
+	Category  string
+	Type      string
+	Submodule *SubmoduleChange // 非子模块时为 nil
 }
 
 // generateCommitMessage 自动生成 commit message
@@ -103,13 +215,13 @@ func generateCommitMessage() (string, error) {
 	// 创建文件类型管理器
 	fileTypeManager, err := config.NewFileTypeManager()
 	if err != nil {
-		return "", fmt.Errorf("创建文件类型管理器失败: %v", err)
+		return "", fmt.Errorf("创建文件类型管理器失败: %w", err)
 	}
 
 	// 分析 Git 变更
-	changes, err := analyzeGitChanges(fileTypeManager)
+	changes, err := analyzeGitChanges()
 	if err != nil {
-		return "", fmt.Errorf("分析 Git 变更失败: %v", err)
+		return "", fmt.Errorf("分析 Git 变更失败: %w", err)
 	}
 
 	// 显示变更详情
@@ -131,9 +243,20 @@ func generateCommitMessage() (string, error) {
 }
 
 // analyzeGitChanges 分析 Git 变更
-func analyzeGitChanges(fileTypeManager *config.FileTypeManager) ([]ChangeInfo, error) {
+//
+// 文件分类使用文件所在目录生效的配置，子目录的 .cyber-zen.yaml 在仓库根目录运行时同样生效。
+func analyzeGitChanges() ([]ChangeInfo, error) {
 	var changes []ChangeInfo
 
+	submodules, err := listSubmodulePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	// porcelain 输出的路径相对于仓库根目录，识别 shebang 时需要据此读取文件
+	repoRoot := config.FindRepoRoot(".")
+	dirManagers := config.NewDirFileTypeManagers()
+
 	// 获取所有变更状态（包括未暂存和已暂存）
 	cmd := exec.Command("git", "status", "--porcelain")
 	output, err := cmd.Output()
@@ -141,7 +264,8 @@ func analyzeGitChanges(fileTypeManager *config.FileTypeManager) ([]ChangeInfo, e
 		return nil, fmt.Errorf("获取 Git 状态失败: %v", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	// 只去除末尾换行，行首空格是状态码的一部分
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
 	
 	for _, line := range lines {
 		if line == "" {
@@ -158,6 +282,18 @@ func analyzeGitChanges(fileTypeManager *config.FileTypeManager) ([]ChangeInfo, e
 		status := string(line[0])
 		file := strings.TrimSpace(line[3:]) // 跳过前3个字符（XY + 空格）
 
+		// 未暂存的变更以工作区状态为准，未跟踪文件视为新增
+		if status == " " {
+			status = string(line[1])
+		} else if status == "?" {
+			status = "A"
+		}
+
+		// AA、DD 等组合同样表示未合并
+		if isUnmergedStatus(line[:2]) {
+			status = "U"
+		}
+
 		// 映射状态到更友好的描述
 		var statusDesc string
 		switch status {
@@ -177,11 +313,24 @@ func analyzeGitChanges(fileTypeManager *config.FileTypeManager) ([]ChangeInfo, e
 			statusDesc = status
 		}
 
+		path := filepath.Join(repoRoot, file)
+		fileTypeManager, err := dirManagers.ForFile(path)
+		if err != nil {
+			return nil, err
+		}
+
 		change := ChangeInfo{
 			File:     file,
 			Status:   statusDesc,
 			Category: fileTypeManager.GetFileCategory(file),
-			Type:     fileTypeManager.GetFileType(file),
+			Type:     fileTypeManager.GetFileType(path),
+		}
+
+		// 子模块按 gitlink 处理，而不是普通文件
+		if submodules[file] {
+			change.Submodule = inspectSubmodule(file)
+			change.Category = submoduleCategory
+			change.Type = "Git 子模块"
 		}
 
 		changes = append(changes, change)
@@ -196,6 +345,10 @@ func displayChanges(changes []ChangeInfo) {
 	
 	color.Yellow("📁 文件变更状态:")
 	for _, change := range changes {
+		if change.Submodule != nil {
+			displaySubmoduleChange(change)
+			continue
+		}
 		switch change.Status {
 		case "A":
 			color.Green("  ✨ 新增: %s", change.File)
@@ -205,6 +358,8 @@ func displayChanges(changes []ChangeInfo) {
 			color.Red("  🗑️  删除: %s", change.File)
 		case "R":
 			color.Yellow("  🔄 重命名: %s", change.File)
+		case "U":
+			color.Red("  ⚔️  未合并: %s", change.File)
 		default:
 			color.Cyan("  ❓ %s: %s", change.Status, change.File)
 		}
@@ -212,6 +367,7 @@ func displayChanges(changes []ChangeInfo) {
 	
 	// 显示变更统计
 	displayChangeStats(changes)
+	warnDirtySubmodules(changes)
 }
 
 // displayChangeStats 显示变更统计
@@ -243,6 +399,11 @@ func generateMessageFromChanges(changes []ChangeInfo, fileTypeManager *config.Fi
 		return "update"
 	}
 
+	// 只有子模块指针变化时生成依赖升级信息
+	if isSubmoduleOnlyChange(changes) {
+		return generateSubmoduleMessage(changes)
+	}
+
 	// 分析变更类型
 	added, modified, deleted := 0, 0, 0
 	categories := make(map[string]int)
@@ -271,8 +432,28 @@ func generateMessageFromChanges(changes []ChangeInfo, fileTypeManager *config.Fi
 	return fmt.Sprintf("%s: %s\n\n%s", commitType, summary, details)
 }
 
+// summaryWords 生成摘要使用的措辞
+type summaryWords struct {
+	Added     string
+	Modified  string
+	Deleted   string
+	Updated   string
+	Separator string
+	Fallback  string
+}
+
+// summaryLanguages 各语言的摘要措辞，由 language 配置选择
+var summaryLanguages = map[string]summaryWords{
+	"zh": {"新增%s", "优化%s", "清理%s", "更新%s", "、", "更新项目文件"},
+	"en": {"add %s", "update %s", "remove %s", "update %s", ", ", "update project files"},
+}
+
 // generateSummary 生成摘要
 func generateSummary(changes []ChangeInfo, categories map[string]int) string {
+	words, ok := summaryLanguages[config.GetLanguage()]
+	if !ok {
+		words = summaryLanguages["zh"]
+	}
 	var parts []string
 	
 	// 根据文件数量生成摘要
@@ -280,11 +461,11 @@ func generateSummary(changes []ChangeInfo, categories map[string]int) string {
 		change := changes[0]
 		switch change.Status {
 		case "A":
-			return fmt.Sprintf("新增%s", change.Category)
+			return fmt.Sprintf(words.Added, change.Category)
 		case "M":
-			return fmt.Sprintf("优化%s", change.Category)
+			return fmt.Sprintf(words.Modified, change.Category)
 		case "D":
-			return fmt.Sprintf("清理%s", change.Category)
+			return fmt.Sprintf(words.Deleted, change.Category)
 		}
 	}
 
@@ -293,7 +474,7 @@ func generateSummary(changes []ChangeInfo, categories map[string]int) string {
 		for category := range categories {
 			parts = append(parts, category)
 		}
-		return fmt.Sprintf("更新%s", strings.Join(parts, "、"))
+		return fmt.Sprintf(words.Updated, strings.Join(parts, words.Separator))
 	}
 
 	// 混合类型
@@ -305,10 +486,10 @@ func generateSummary(changes []ChangeInfo, categories map[string]int) string {
 	}
 	
 	if len(mainCategories) > 0 {
-		return fmt.Sprintf("更新%s", strings.Join(mainCategories, "、"))
+		return fmt.Sprintf(words.Updated, strings.Join(mainCategories, words.Separator))
 	}
 	
-	return "更新项目文件"
+	return words.Fallback
 }
 
 // generateDetails 生成详细信息
@@ -316,6 +497,11 @@ func generateDetails(changes []ChangeInfo, fileTypeManager *config.FileTypeManag
 	var details []string
 	
 	for _, change := range changes {
+		if change.Submodule != nil && change.Submodule.Moved() {
+			details = append(details, submoduleDetail(change.Submodule))
+			continue
+		}
+
 		var action string
 		switch change.Status {
 		case "A":
@@ -357,6 +543,16 @@ func checkGitRepo() error {
 	return nil
 }
 
+// gitOutput 执行 Git 命令并返回去除首尾空白的输出
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // execGitCommand 执行 Git 命令
 func execGitCommand(args ...string) error {
 	cmd := exec.Command("git", args...)
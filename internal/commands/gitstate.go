@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gitOperation 进行中的 Git 操作
+type gitOperation struct {
+	Name     string // 操作标识: merge, rebase, am, cherry-pick, revert, bisect
+	Desc     string // 中文描述
+	Guidance string // 处理建议
+}
+
+// gitOperations 进行中操作的检测规则（按 .git 下的状态文件判断）
+var gitOperations = []struct {
+	Path      string
+	Operation gitOperation
+}{
+	{"rebase-merge", gitOperation{"rebase", "变基 (rebase)", "解决冲突并 git add 后执行 git rebase --continue，或执行 git rebase --abort 放弃"}},
+	{"rebase-apply/applying", gitOperation{"am", "补丁应用 (am)", "解决冲突并 git add 后执行 git am --continue，或执行 git am --abort 放弃"}},
+	{"rebase-apply", gitOperation{"rebase", "变基 (rebase)", "解决冲突并 git add 后执行 git rebase --continue，或执行 git rebase --abort 放弃"}},
+	{"CHERRY_PICK_HEAD", gitOperation{"cherry-pick", "拣选 (cherry-pick)", "解决冲突并 git add 后执行 git cherry-pick --continue，或执行 git cherry-pick --abort 放弃"}},
+	{"REVERT_HEAD", gitOperation{"revert", "回滚 (revert)", "解决冲突并 git add 后执行 git revert --continue，或执行 git revert --abort 放弃"}},
+	{"BISECT_LOG", gitOperation{"bisect", "二分查找 (bisect)", "完成二分查找后执行 git bisect reset 回到原分支"}},
+	{"MERGE_HEAD", gitOperation{"merge", "合并 (merge)", "解决冲突并 git add 后重新运行 gcm 完成合并，或执行 git merge --abort 放弃"}},
+}
+
+// conflictMarker 冲突标记位置
+type conflictMarker struct {
+	File string
+	Line int
+}
+
+// repoState 仓库当前状态
+type repoState struct {
+	Operation *gitOperation    // 进行中的操作，nil 表示没有
+	Unmerged  []string         // 未合并的路径
+	Markers   []conflictMarker // 暂存文件中残留的冲突标记
+}
+
+// detectGitOperation 检测进行中的 Git 操作
+func detectGitOperation() (*gitOperation, error) {
+	for _, rule := range gitOperations {
+		path, err := gitOutput("rev-parse", "--git-path", rule.Path)
+		if err != nil {
+			return nil, fmt.Errorf("获取 Git 状态文件路径失败: %v", err)
+		}
+		if _, err := os.Stat(path); err == nil {
+			op := rule.Operation
+			return &op, nil
+		}
+	}
+	return nil, nil
+}
+
+// listUnmergedPaths 列出未合并（存在冲突）的路径
+func listUnmergedPaths() ([]string, error) {
+	paths, err := gitPaths("diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, fmt.Errorf("获取未合并文件失败: %v", err)
+	}
+	return paths, nil
+}
+
+// findStagedConflictMarkers 扫描暂存区文件中残留的冲突标记
+func findStagedConflictMarkers() ([]conflictMarker, error) {
+	// 子模块没有可读取的文件内容，不列出
+	files, err := gitPaths("diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR", "--ignore-submodules=all")
+	if err != nil {
+		return nil, fmt.Errorf("获取暂存文件失败: %v", err)
+	}
+
+	var markers []conflictMarker
+	for _, file := range files {
+		// :<path> 中的路径相对于仓库根目录，与 git diff 的输出一致
+		content, err := exec.Command("git", "show", ":"+file).Output()
+		if err != nil {
+			return nil, fmt.Errorf("读取暂存文件 %s 失败: %v", file, err)
+		}
+		if line := conflictMarkerLine(content); line > 0 {
+			markers = append(markers, conflictMarker{File: file, Line: line})
+		}
+	}
+	return markers, nil
+}
+
+// gitPaths 执行带 -z 的 Git 命令，按 NUL 拆分路径
+//
+// 不带 -z 时含中文或特殊字符的路径会按 core.quotePath 加引号转义，无法直接用于后续命令。
+func gitPaths(args ...string) ([]string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// conflictMarkerLine 返回内容中第一个冲突标记所在行号，没有则返回 0
+func conflictMarkerLine(content []byte) int {
+	// 二进制文件不检查
+	if bytes.IndexByte(content, 0) >= 0 {
+		return 0
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "<<<<<<<" || line == ">>>>>>>" ||
+			strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return lineNo
+		}
+	}
+	return 0
+}
+
+// inspectRepoState 检查进行中的操作与未合并路径
+func inspectRepoState() (*repoState, error) {
+	op, err := detectGitOperation()
+	if err != nil {
+		return nil, err
+	}
+	unmerged, err := listUnmergedPaths()
+	if err != nil {
+		return nil, err
+	}
+	return &repoState{Operation: op, Unmerged: unmerged}, nil
+}
+
+// blockingError 返回阻止提交的错误，包含处理建议；可以继续时返回 nil
+func (s *repoState) blockingError() error {
+	var b strings.Builder
+
+	if len(s.Unmerged) > 0 {
+		b.WriteString("存在未解决冲突的文件:\n")
+		for _, file := range s.Unmerged {
+			fmt.Fprintf(&b, "  ✗ %s\n", file)
+		}
+	}
+
+	if len(s.Markers) > 0 {
+		b.WriteString("暂存文件中残留冲突标记:\n")
+		for _, marker := range s.Markers {
+			fmt.Fprintf(&b, "  ✗ %s:%d\n", marker.File, marker.Line)
+		}
+	}
+
+	// 合并在冲突解决后可以由 gcm 完成，其他操作需要使用各自的 --continue
+	if s.Operation != nil && s.Operation.Name != "merge" {
+		fmt.Fprintf(&b, "%s正在进行中，gcm 不会在此状态下提交\n", s.Operation.Desc)
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	if s.Operation != nil {
+		fmt.Fprintf(&b, "建议: %s", s.Operation.Guidance)
+	} else {
+		b.WriteString("建议: 解决冲突并删除冲突标记后重新运行 gcm")
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// isMerging 是否处于可以完成的合并状态
+func (s *repoState) isMerging() bool {
+	return s.Operation != nil && s.Operation.Name == "merge"
+}
+
+// generateMergeMessage 生成合并提交信息
+func generateMergeMessage() string {
+	// 优先使用 Git 为本次合并准备的 MERGE_MSG
+	if path, err := gitOutput("rev-parse", "--git-path", "MERGE_MSG"); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			var lines []string
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.HasPrefix(line, "#") {
+					continue
+				}
+				lines = append(lines, line)
+			}
+			if msg := strings.TrimSpace(strings.Join(lines, "\n")); msg != "" {
+				return msg
+			}
+		}
+	}
+
+	branch, _ := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	mergeHead, _ := gitOutput("rev-parse", "--short", "MERGE_HEAD")
+	if source, err := gitOutput("name-rev", "--name-only", "MERGE_HEAD"); err == nil && source != "" && source != "undefined" {
+		return fmt.Sprintf("Merge branch '%s' into %s", source, branch)
+	}
+	return fmt.Sprintf("Merge commit '%s' into %s", mergeHead, branch)
+}
+
+// isUnmergedStatus 判断 porcelain 状态码是否表示未合并
+func isUnmergedStatus(xy string) bool {
+	switch xy {
+	case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConflictMarkerLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"无冲突", "package main\n\nfunc main() {}\n", 0},
+		{"残留冲突", "a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> feature\n", 2},
+		{"只残留结束标记", "a\n>>>>>>> feature\n", 2},
+		{"行内出现不算", "// <<<<<<< 不是冲突\n", 0},
+		{"二进制文件", "\x00<<<<<<< HEAD\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if line := conflictMarkerLine([]byte(tt.content)); line != tt.line {
+				t.Errorf("期望行号 %d，实际为 %d", tt.line, line)
+			}
+		})
+	}
+}
+
+func TestInspectRepoStateMerge(t *testing.T) {
+	repo := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte(content), 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	write("base\n")
+	git("add", ".")
+	git("commit", "-m", "base")
+	git("checkout", "-b", "feature")
+	write("feature\n")
+	git("commit", "-am", "feature")
+	git("checkout", "-")
+	write("main\n")
+	git("commit", "-am", "main")
+
+	// 制造合并冲突
+	_ = exec.Command("git", "merge", "feature").Run()
+
+	state, err := inspectRepoState()
+	if err != nil {
+		t.Fatalf("检查仓库状态失败: %v", err)
+	}
+	if !state.isMerging() {
+		t.Fatal("期望检测到进行中的合并")
+	}
+	if len(state.Unmerged) != 1 || state.Unmerged[0] != "a.txt" {
+		t.Errorf("期望未合并文件为 [a.txt]，实际为 %v", state.Unmerged)
+	}
+	if err := state.blockingError(); err == nil {
+		t.Error("期望存在冲突时拒绝提交")
+	}
+
+	// 不解决冲突直接暂存，冲突标记会被检测到
+	git("add", "a.txt")
+	state, _ = inspectRepoState()
+	state.Markers, err = findStagedConflictMarkers()
+	if err != nil {
+		t.Fatalf("扫描冲突标记失败: %v", err)
+	}
+	if len(state.Markers) != 1 || state.Markers[0].Line != 1 {
+		t.Errorf("期望在 a.txt:1 检测到冲突标记，实际为 %v", state.Markers)
+	}
+
+	// 解决冲突后允许完成合并
+	write("resolved\n")
+	git("add", "a.txt")
+	state, _ = inspectRepoState()
+	state.Markers, _ = findStagedConflictMarkers()
+	if err := state.blockingError(); err != nil {
+		t.Errorf("期望冲突解决后允许提交，实际返回: %v", err)
+	}
+	if msg := generateMergeMessage(); !strings.Contains(msg, "feature") {
+		t.Errorf("期望合并信息包含分支名 feature，实际为 %q", msg)
+	}
+}
+
+func TestFindStagedConflictMarkersQuotedPaths(t *testing.T) {
+	setupTestRepo(t)
+	// 默认 core.quotePath 下这些路径会被加引号转义
+	files := map[string]string{
+		"中文.txt":         "a\n<<<<<<< HEAD\nb\n",
+		"with space.txt": "ok\n",
+		"tab\tname.txt":  "x\ny\n>>>>>>> feature\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+	if output, err := exec.Command("git", "add", ".").CombinedOutput(); err != nil {
+		t.Fatalf("git add 失败: %v\n%s", err, output)
+	}
+
+	markers, err := findStagedConflictMarkers()
+	if err != nil {
+		t.Fatalf("扫描冲突标记失败: %v", err)
+	}
+	found := map[string]int{}
+	for _, marker := range markers {
+		found[marker.File] = marker.Line
+	}
+	if len(found) != 2 || found["中文.txt"] != 2 || found["tab\tname.txt"] != 3 {
+		t.Errorf("期望在中文.txt:2 和 tab\\tname.txt:3 检测到冲突标记，实际为 %v", markers)
+	}
+}
@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+// currentPairFile 当前结对成员文件名（位于安装目录下）
+const currentPairFile = "current-pair"
+
+// newPairCommand 创建 pair 命令
+func newPairCommand() *cobra.Command {
+	var clear bool
+
+	cmd := &cobra.Command{
+		Use:   "pair [alias,alias...]",
+		Short: "设置当前结对成员",
+		Long: `设置当前结对编程的成员，之后每次 gcm 都会自动追加 Co-authored-by 署名，
+直到使用 --clear 清除。
+
+成员别名来自配置文件中的团队名单 (config.yaml):
+  team:
+    alice:
+      name: Alice Zhang
+      email: alice@example.com
+
+示例:
+  cyber-zen pair alice,bob   # 设置结对成员
+  cyber-zen pair             # 查看当前结对成员
+  cyber-zen pair --clear     # 清除结对成员`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if clear {
+				return clearCurrentPair()
+			}
+			if len(args) == 0 {
+				return showCurrentPair()
+			}
+			return setCurrentPair(parseAliases(args[0]))
+		},
+	}
+
+	cmd.Flags().BoolVar(&clear, "clear", false, "清除当前结对成员")
+
+	return cmd
+}
+
+// showCurrentPair 显示当前结对成员
+func showCurrentPair() error {
+	aliases, err := loadCurrentPair()
+	if err != nil {
+		return err
+	}
+	if len(aliases) == 0 {
+		color.Yellow("当前没有结对成员")
+		return nil
+	}
+
+	members, err := resolveCoAuthors(aliases)
+	if err != nil {
+		return err
+	}
+	color.Cyan("当前结对成员:")
+	for _, member := range members {
+		fmt.Printf("  %s <%s>\n", member.Name, member.Email)
+	}
+	return nil
+}
+
+// setCurrentPair 保存当前结对成员
+func setCurrentPair(aliases []string) error {
+	if len(aliases) == 0 {
+		return fmt.Errorf("请至少指定一个成员别名")
+	}
+	// 先校验别名，避免保存无效成员
+	if _, err := resolveCoAuthors(aliases); err != nil {
+		return err
+	}
+
+	path, err := currentPairPath()
+	if err != nil {
+		return err
+	}
+	if err := config.EnsureInstallDir(); err != nil {
+		return fmt.Errorf("创建安装目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(aliases, ",")+"\n"), 0644); err != nil {
+		return fmt.Errorf("保存结对成员失败: %v", err)
+	}
+
+	color.Green("✓ 已设置结对成员: %s", strings.Join(aliases, ", "))
+	return nil
+}
+
+// clearCurrentPair 清除当前结对成员
+func clearCurrentPair() error {
+	path, err := currentPairPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("清除结对成员失败: %v", err)
+	}
+	color.Green("✓ 已清除结对成员")
+	return nil
+}
+
+// loadCurrentPair 读取当前结对成员别名
+func loadCurrentPair() ([]string, error) {
+	path, err := currentPairPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取结对成员失败: %v", err)
+	}
+	return parseAliases(string(data)), nil
+}
+
+// currentPairPath 获取结对成员文件路径
+func currentPairPath() (string, error) {
+	installDir := config.GetInstallDir()
+	if installDir == "" {
+		return "", fmt.Errorf("安装目录未配置")
+	}
+	return filepath.Join(installDir, currentPairFile), nil
+}
+
+// parseAliases 解析逗号分隔的别名列表（去重、忽略空白）
+func parseAliases(value string) []string {
+	var aliases []string
+	seen := make(map[string]bool)
+	for _, alias := range strings.Split(value, ",") {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// resolveCoAuthors 将别名解析为团队成员
+func resolveCoAuthors(aliases []string) ([]config.TeamMember, error) {
+	var members []config.TeamMember
+	for _, alias := range aliases {
+		member, ok := config.GetTeamMember(alias)
+		if !ok {
+			return nil, fmt.Errorf("团队名单中不存在成员: %s", alias)
+		}
+		if member.Name == "" || member.Email == "" {
+			return nil, fmt.Errorf("成员 %s 缺少 name 或 email", alias)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// collectCoAuthors 合并 --with 与当前结对成员
+func collectCoAuthors(with []string) ([]config.TeamMember, error) {
+	sticky, err := loadCurrentPair()
+	if err != nil {
+		// 未配置安装目录时不影响显式指定的成员
+		sticky = nil
+	}
+	aliases := parseAliases(strings.Join(append(sticky, with...), ","))
+	return resolveCoAuthors(aliases)
+}
+
+// appendCoAuthorTrailers 在提交信息末尾追加 Co-authored-by 署名
+func appendCoAuthorTrailers(msg string, members []config.TeamMember) string {
+	var trailers []string
+	for _, member := range members {
+		trailer := fmt.Sprintf("Co-authored-by: %s <%s>", member.Name, member.Email)
+		if strings.Contains(msg, trailer) {
+			continue
+		}
+		trailers = append(trailers, trailer)
+	}
+	if len(trailers) == 0 {
+		return msg
+	}
+	return strings.TrimRight(msg, "\n") + "\n\n" + strings.Join(trailers, "\n")
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+func TestParseAliases(t *testing.T) {
+	aliases := parseAliases(" Alice, bob,,alice ")
+	if len(aliases) != 2 || aliases[0] != "alice" || aliases[1] != "bob" {
+		t.Errorf("期望 [alice bob]，实际为 %v", aliases)
+	}
+}
+
+func TestAppendCoAuthorTrailers(t *testing.T) {
+	members := []config.TeamMember{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	}
+
+	msg := appendCoAuthorTrailers("feat: 新增源代码\n", members)
+	expected := "feat: 新增源代码\n\nCo-authored-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>"
+	if msg != expected {
+		t.Errorf("期望:\n%s\n实际:\n%s", expected, msg)
+	}
+
+	// 已存在的署名不会重复追加
+	if again := appendCoAuthorTrailers(msg, members); again != msg {
+		t.Errorf("期望署名不重复，实际为:\n%s", again)
+	}
+}
+
+func TestCollectCoAuthors(t *testing.T) {
+	setupTestInstallDir(t)
+	config.GlobalConfig.Team = map[string]config.TeamMember{
+		"alice": {Name: "Alice", Email: "alice@example.com"},
+		"bob":   {Name: "Bob", Email: "bob@example.com"},
+	}
+
+	if err := setCurrentPair([]string{"alice"}); err != nil {
+		t.Fatalf("设置结对成员失败: %v", err)
+	}
+
+	members, err := collectCoAuthors([]string{"bob", "alice"})
+	if err != nil {
+		t.Fatalf("解析共同作者失败: %v", err)
+	}
+	if len(members) != 2 || members[0].Name != "Alice" || members[1].Name != "Bob" {
+		t.Errorf("期望 [Alice Bob]，实际为 %v", members)
+	}
+
+	if err := clearCurrentPair(); err != nil {
+		t.Fatalf("清除结对成员失败: %v", err)
+	}
+	if members, _ := collectCoAuthors(nil); len(members) != 0 {
+		t.Errorf("期望清除后没有共同作者，实际为 %v", members)
+	}
+
+	if _, err := collectCoAuthors([]string{"carol"}); err == nil {
+		t.Error("期望未知成员返回错误，但没有")
+	}
+}
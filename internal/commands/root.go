@@ -1,11 +1,49 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
 )
 
+// globalFlag 可以覆盖配置项的全局标志
+type globalFlag struct {
+	Key   string // 配置键
+	Name  string // 标志名
+	Usage string
+}
+
+// globalFlags 全局标志，每个标志对应一个配置键和一个 CYBER_ZEN_* 环境变量
+var globalFlags = []globalFlag{
+	{"install_dir", "install-dir", "安装目录"},
+	{"config_dir", "config-dir", "用户配置目录"},
+	{"profile", "profile", "使用的配置档案（config.yaml 中的 profiles）"},
+	{"language", "language", "生成提交信息的语言: zh 或 en"},
+	{"gcm.push", "gcm-push", "gcm 提交后是否推送"},
+	{"gcm.recurse_submodules", "gcm-recurse-submodules", "gcm 是否先提交有修改的子模块"},
+	{"gcm.with", "gcm-with", "gcm 默认追加的共同作者别名"},
+	{"compress.rate", "compress-rate", "compress 默认压缩比率"},
+	{"compress.dist", "compress-dist", "compress 默认目标路径"},
+	{"compress.filter", "compress-filter", "compress 默认缩放滤波器"},
+	{"compress.target_size", "compress-target-size", "compress 默认目标文件大小"},
+	{"compress.format", "compress-format", "compress 默认输出格式"},
+	{"compress.background", "compress-background", "compress 转换为 JPEG 时的默认背景色"},
+	{"compress.metadata", "compress-metadata", "compress 默认的元数据处理方式"},
+	{"compress.min_savings", "compress-min-savings", "compress 重新编码至少节省的百分比"},
+	{"server.host", "server-host", "server 默认监听地址"},
+	{"server.port", "server-port", "server 默认端口"},
+}
+
 // NewRootCommand 创建根命令
 func NewRootCommand() *cobra.Command {
+	// 配置警告输出到标准错误，避免影响 config get 等命令的输出
+	config.WarningHandler = func(message string) {
+		fmt.Fprintln(os.Stderr, color.YellowString("⚠️  %s", message))
+	}
+
 	rootCmd := &cobra.Command{
 		Use:   "cyber-zen",
 		Short: "Cyber Zen Tools - 跨平台命令行工具集",
@@ -14,11 +52,32 @@ func NewRootCommand() *cobra.Command {
 
 支持的命令:
   gcm        - Git 提交并推送
+  undo       - 撤销最近一次 gcm 提交
+  pair       - 设置结对成员（Co-authored-by）
   status     - 显示工具状态
   uninstall  - 卸载程序
   compress   - 压缩图片文件
-  server     - 启动静态文件服务器`,
+  config     - 管理配置文件（查看、校验、迁移、JSON Schema）
+  server     - 启动静态文件服务器
+
+配置优先级（从高到低）:
+  子命令标志 > 全局标志 > CYBER_ZEN_* 环境变量 > 仓库 .cyber-zen.yaml
+  > 配置档案 (--profile) > config.yaml > 默认值
+  环境变量名由配置键转换而来，例如 compress.rate 对应 CYBER_ZEN_COMPRESS_RATE`,
 		Version: "1.0.0",
+		// 在解析标志之后初始化配置，使全局标志生效
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Init(); err != nil {
+				// config 子命令用于查看和修复配置，配置有误时仍然运行
+				if isConfigCommand(cmd) {
+					config.WarningHandler(fmt.Sprintf("配置初始化失败: %v", err))
+					return nil
+				}
+				cmd.SilenceUsage = true
+				return fmt.Errorf("配置初始化失败: %v", err)
+			}
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// 如果没有子命令，显示帮助
 			if len(args) == 0 {
@@ -29,10 +88,47 @@ func NewRootCommand() *cobra.Command {
 
 	// 添加子命令
 	rootCmd.AddCommand(newGcmCommand())
+	rootCmd.AddCommand(newUndoCommand())
+	rootCmd.AddCommand(newPairCommand())
 	rootCmd.AddCommand(newStatusCommand())
 	rootCmd.AddCommand(newUninstallCommand())
 	rootCmd.AddCommand(newCompressCommand())
 	rootCmd.AddCommand(newServerCommand())
+	rootCmd.AddCommand(newConfigCommand())
+
+	addGlobalFlags(rootCmd)
 
 	return rootCmd
+}
+
+// isConfigCommand 判断是否为 config 命令或其子命令
+func isConfigCommand(cmd *cobra.Command) bool {
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		if c.Name() == "config" && c.Parent() == c.Root() {
+			return true
+		}
+	}
+	return false
+}
+
+// addGlobalFlags 注册全局标志并绑定到对应的配置键
+func addGlobalFlags(rootCmd *cobra.Command) {
+	flags := rootCmd.PersistentFlags()
+	for _, flag := range globalFlags {
+		usage := fmt.Sprintf("%s（环境变量 %s）", flag.Usage, config.EnvName(flag.Key))
+		switch flag.Key {
+		case "gcm.push", "gcm.recurse_submodules":
+			flags.Bool(flag.Name, false, usage)
+		case "gcm.with":
+			flags.StringSlice(flag.Name, nil, usage)
+		case "compress.rate", "compress.min_savings":
+			flags.Float64(flag.Name, 0, usage)
+		case "server.port":
+			flags.Int(flag.Name, 0, usage)
+		default:
+			flags.String(flag.Name, "", usage)
+		}
+		// 标志只在显式指定时生效，默认值由配置提供
+		_ = config.BindFlag(flag.Key, flags.Lookup(flag.Name))
+	}
 } 
\ No newline at end of file
@@ -10,11 +10,13 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
 )
 
 // newServerCommand 创建服务器命令
 func newServerCommand() *cobra.Command {
 	var serverPort int
+	var serverHost string
 
 	serverCmd := &cobra.Command{
 		Use:   "server",
@@ -31,18 +33,28 @@ func newServerCommand() *cobra.Command {
   cyber-zen server /path/to/dir -p 5000  # 在指定目录启动服务器，端口 5000
 
 选项:
-  -p, --port int    指定端口号 (默认 3000)`,
+  -p, --port int      指定端口号 (默认 3000，可通过 server.port 配置)
+      --host string   监听地址 (默认所有地址，可通过 server.host 配置)`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			runServer(cmd, args, serverPort)
+			// 未显式指定时使用配置中的默认值
+			defaults := config.GetServerConfig()
+			if !cmd.Flags().Changed("port") && defaults.Port > 0 {
+				serverPort = defaults.Port
+			}
+			if !cmd.Flags().Changed("host") {
+				serverHost = defaults.Host
+			}
+			runServer(cmd, args, serverHost, serverPort)
 		},
 	}
 
 	serverCmd.Flags().IntVarP(&serverPort, "port", "p", 3000, "服务器端口")
+	serverCmd.Flags().StringVar(&serverHost, "host", "", "监听地址（默认所有地址）")
 	return serverCmd
 }
 
-func runServer(cmd *cobra.Command, args []string, port int) {
+func runServer(cmd *cobra.Command, args []string, host string, port int) {
 	var serverDir string
 	
 	// 设置默认目录
@@ -72,14 +84,18 @@ func runServer(cmd *cobra.Command, args []string, port int) {
 	}
 
 	// 检查端口是否被占用
-	if isPortInUse(port) {
+	if isPortInUse(host, port) {
 		fmt.Printf("❌ 错误: 端口 %d 已被占用\n", port)
 		os.Exit(1)
 	}
 
 	fmt.Printf("🚀 启动静态文件服务器...\n")
 	fmt.Printf("📁 服务目录: %s\n", absPath)
-	fmt.Printf("🌐 服务地址: http://localhost:%d\n", port)
+	displayHost := host
+	if displayHost == "" {
+		displayHost = "localhost"
+	}
+	fmt.Printf("🌐 服务地址: http://%s\n", net.JoinHostPort(displayHost, strconv.Itoa(port)))
 	fmt.Printf("📋 按 Ctrl+C 停止服务器\n\n")
 
 	// 创建文件服务器
@@ -92,7 +108,7 @@ func runServer(cmd *cobra.Command, args []string, port int) {
 	})
 
 	// 启动服务器
-	addr := ":" + strconv.Itoa(port)
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	fmt.Printf("✅ 服务器已启动，监听端口 %d\n", port)
 	
 	if err := http.ListenAndServe(addr, handler); err != nil {
@@ -101,8 +117,8 @@ func runServer(cmd *cobra.Command, args []string, port int) {
 	}
 }
 
-func isPortInUse(port int) bool {
-	addr := ":" + strconv.Itoa(port)
+func isPortInUse(host string, port int) bool {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return true
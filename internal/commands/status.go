@@ -29,6 +29,13 @@ func showStatus() {
 	// 显示安装目录
 	installDir := config.GetInstallDir()
 	color.Cyan("安装目录: %s", installDir)
+	color.Cyan("配置目录: %s", config.GetUserConfigDir())
+	color.Cyan("语言: %s", config.GetLanguage())
+	if profile := config.GetActiveProfile(); profile.Name != "" {
+		color.Cyan("配置档案: %s（%s）", profile.Name, profile.Source)
+	} else {
+		color.Cyan("配置档案: 未使用")
+	}
 	
 	// 显示版本信息
 	color.Cyan("版本: 1.0.0")
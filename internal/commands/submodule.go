@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// submoduleCategory 子模块变更的分类
+const submoduleCategory = "子模块"
+
+// SubmoduleChange 子模块指针变更信息
+type SubmoduleChange struct {
+	Path   string   // 子模块路径
+	OldSHA string   // 父仓库 HEAD 中记录的提交，新增子模块时为空
+	NewSHA string   // 子模块当前检出的提交
+	Log    []string // OldSHA..NewSHA 之间的单行日志
+	Dirty  bool     // 子模块工作区是否有未提交修改
+}
+
+// Moved 子模块指针是否发生变化
+func (s *SubmoduleChange) Moved() bool {
+	return s.NewSHA != "" && s.OldSHA != s.NewSHA
+}
+
+// repoTopLevel 返回当前仓库的根目录
+//
+// 子模块路径都相对于仓库根目录，在子目录中运行时不能直接用作 -C 的参数。
+func repoTopLevel() (string, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("获取仓库根目录失败: %v", err)
+	}
+	return root, nil
+}
+
+// listSubmodulePaths 列出索引中的所有子模块路径（gitlink 条目），路径相对于仓库根目录
+func listSubmodulePaths() (map[string]bool, error) {
+	root, err := repoTopLevel()
+	if err != nil {
+		return nil, err
+	}
+	// 在根目录执行才能列出整个仓库，-z 避免含特殊字符的路径被加引号
+	output, err := exec.Command("git", "-C", root, "ls-files", "--stage", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取索引条目失败: %v", err)
+	}
+
+	paths := make(map[string]bool)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// 格式: <mode> <sha> <stage>\t<path>，子模块的 mode 为 160000
+		if !strings.HasPrefix(entry, "160000 ") {
+			continue
+		}
+		if i := strings.Index(entry, "\t"); i >= 0 {
+			paths[entry[i+1:]] = true
+		}
+	}
+	return paths, nil
+}
+
+// inspectSubmodule 获取子模块的新旧提交、日志和工作区状态，path 相对于仓库根目录
+func inspectSubmodule(path string) *SubmoduleChange {
+	change := &SubmoduleChange{Path: path}
+	root, _ := repoTopLevel()
+	dir := filepath.Join(root, path)
+
+	// HEAD:<path> 中不以 ./ 开头的路径相对于仓库根目录
+	change.OldSHA, _ = gitOutput("rev-parse", "HEAD:"+path)
+	change.NewSHA, _ = gitOutput("-C", dir, "rev-parse", "HEAD")
+
+	if status, err := gitOutput("-C", dir, "status", "--porcelain"); err == nil && status != "" {
+		change.Dirty = true
+	}
+
+	if change.OldSHA != "" && change.Moved() {
+		if log, err := gitOutput("-C", dir, "log", "--oneline", change.OldSHA+".."+change.NewSHA); err == nil && log != "" {
+			change.Log = strings.Split(log, "\n")
+		}
+	}
+	return change
+}
+
+// commitDirtySubmodules 依次进入有未提交修改的子模块执行 gcm
+func commitDirtySubmodules(opts gcmOptions) error {
+	root, err := repoTopLevel()
+	if err != nil {
+		return err
+	}
+	paths, err := listSubmodulePaths()
+	if err != nil {
+		return err
+	}
+
+	var sorted []string
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		change := inspectSubmodule(path)
+		if !change.Dirty {
+			continue
+		}
+
+		dir := filepath.Join(root, path)
+		color.Cyan("\n📦 提交子模块: %s", path)
+		if branch, _ := gitOutput("-C", dir, "rev-parse", "--abbrev-ref", "HEAD"); branch == "HEAD" {
+			return fmt.Errorf("子模块 %s 处于游离 HEAD 状态，请先在子模块中切换到分支", path)
+		}
+
+		if err := runGcmInDir(dir, opts); err != nil {
+			return fmt.Errorf("提交子模块 %s 失败: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// runGcmInDir 在指定目录中执行 gcm（自动生成提交信息）
+func runGcmInDir(dir string, opts gcmOptions) error {
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("获取当前目录失败: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("进入目录失败: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+
+	return runGcm(nil, opts)
+}
+
+// warnDirtySubmodules 提示未提交修改的子模块不会被包含
+func warnDirtySubmodules(changes []ChangeInfo) {
+	for _, change := range changes {
+		if change.Submodule != nil && change.Submodule.Dirty {
+			color.Yellow("⚠️  子模块 %s 有未提交修改，这些修改不会被包含；可使用 --recurse-submodules 先提交子模块", change.File)
+		}
+	}
+}
+
+// displaySubmoduleChange 显示子模块指针变更
+func displaySubmoduleChange(change ChangeInfo) {
+	sub := change.Submodule
+	switch {
+	case sub.OldSHA == "":
+		color.Magenta("  📦 新增子模块: %s @ %s", change.File, shortSHA(sub.NewSHA))
+	case sub.Moved():
+		color.Magenta("  📦 子模块: %s %s..%s", change.File, shortSHA(sub.OldSHA), shortSHA(sub.NewSHA))
+	default:
+		color.Magenta("  📦 子模块: %s（指针未变化）", change.File)
+	}
+	for _, line := range sub.Log {
+		fmt.Printf("       %s\n", line)
+	}
+	if sub.Dirty {
+		color.Yellow("       (子模块有未提交修改)")
+	}
+}
+
+// isSubmoduleOnlyChange 是否所有变更都是子模块指针变化
+func isSubmoduleOnlyChange(changes []ChangeInfo) bool {
+	if len(changes) == 0 {
+		return false
+	}
+	for _, change := range changes {
+		if change.Submodule == nil || !change.Submodule.Moved() {
+			return false
+		}
+	}
+	return true
+}
+
+// generateSubmoduleMessage 生成子模块升级的提交信息
+func generateSubmoduleMessage(changes []ChangeInfo) string {
+	var summary string
+	if len(changes) == 1 {
+		sub := changes[0].Submodule
+		if sub.OldSHA == "" {
+			summary = fmt.Sprintf("chore(deps): add %s at %s", sub.Path, shortSHA(sub.NewSHA))
+		} else {
+			summary = fmt.Sprintf("chore(deps): bump %s from %s to %s", sub.Path, shortSHA(sub.OldSHA), shortSHA(sub.NewSHA))
+		}
+	} else {
+		var names []string
+		for _, change := range changes {
+			names = append(names, change.Submodule.Path)
+		}
+		summary = fmt.Sprintf("chore(deps): bump %s", strings.Join(names, ", "))
+	}
+
+	var details []string
+	for _, change := range changes {
+		details = append(details, submoduleDetail(change.Submodule))
+		for _, line := range change.Submodule.Log {
+			details = append(details, "  "+line)
+		}
+	}
+	return fmt.Sprintf("%s\n\n%s", summary, strings.Join(details, "\n"))
+}
+
+// submoduleDetail 子模块变更的详情行
+func submoduleDetail(sub *SubmoduleChange) string {
+	if sub.OldSHA == "" {
+		return fmt.Sprintf("- 新增子模块 %s (%s)", sub.Path, shortSHA(sub.NewSHA))
+	}
+	return fmt.Sprintf("- 更新子模块 %s (%s..%s)", sub.Path, shortSHA(sub.OldSHA), shortSHA(sub.NewSHA))
+}
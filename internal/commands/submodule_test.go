@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmoduleBump(t *testing.T) {
+	repo := setupTestRepo(t)
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s 失败: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	// 创建被引用的子模块仓库
+	libDir := t.TempDir()
+	run(libDir, "init")
+	run(libDir, "config", "user.name", "Test User")
+	run(libDir, "config", "user.email", "test@example.com")
+	if err := os.WriteFile(filepath.Join(libDir, "lib.go"), []byte("package lib\n"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	run(libDir, "add", ".")
+	run(libDir, "commit", "-m", "init lib")
+
+	run(repo, "-c", "protocol.file.allow=always", "submodule", "add", libDir, "lib")
+	run(repo, "commit", "-m", "add lib")
+
+	paths, err := listSubmodulePaths()
+	if err != nil {
+		t.Fatalf("获取子模块失败: %v", err)
+	}
+	if !paths["lib"] {
+		t.Fatalf("期望识别子模块 lib，实际为 %v", paths)
+	}
+
+	// 在子模块中产生新提交，使指针变化
+	subDir := filepath.Join(repo, "lib")
+	run(subDir, "config", "user.name", "Test User")
+	run(subDir, "config", "user.email", "test@example.com")
+	if err := os.WriteFile(filepath.Join(subDir, "lib.go"), []byte("package lib\n\nconst V = 2\n"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	run(subDir, "commit", "-am", "bump version")
+
+	sub := inspectSubmodule("lib")
+	if !sub.Moved() || sub.Dirty {
+		t.Fatalf("期望子模块指针变化且工作区干净，实际为 %+v", sub)
+	}
+	if len(sub.Log) != 1 || !strings.Contains(sub.Log[0], "bump version") {
+		t.Errorf("期望日志包含 bump version，实际为 %v", sub.Log)
+	}
+
+	// 在子目录中运行时，路径仍相对于仓库根目录
+	docsDir := filepath.Join(repo, "docs")
+	if err := os.Mkdir(docsDir, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.Chdir(docsDir); err != nil {
+		t.Fatalf("切换到子目录失败: %v", err)
+	}
+	if paths, err := listSubmodulePaths(); err != nil || !paths["lib"] {
+		t.Errorf("在子目录中期望识别子模块 lib，实际为 %v, %v", paths, err)
+	}
+	if fromDocs := inspectSubmodule("lib"); fromDocs.OldSHA != sub.OldSHA || fromDocs.NewSHA != sub.NewSHA || len(fromDocs.Log) != 1 {
+		t.Errorf("在子目录中期望得到相同的子模块信息，实际为 %+v", fromDocs)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatalf("切换回仓库失败: %v", err)
+	}
+
+	changes := []ChangeInfo{{File: "lib", Status: "M", Category: submoduleCategory, Submodule: sub}}
+	if !isSubmoduleOnlyChange(changes) {
+		t.Fatal("期望识别为只有子模块变化")
+	}
+	msg := generateSubmoduleMessage(changes)
+	expected := "chore(deps): bump lib from " + shortSHA(sub.OldSHA) + " to " + shortSHA(sub.NewSHA)
+	if !strings.HasPrefix(msg, expected) {
+		t.Errorf("期望提交信息以 %q 开头，实际为:\n%s", expected, msg)
+	}
+
+	// 子模块有未提交修改
+	if err := os.WriteFile(filepath.Join(subDir, "new.go"), []byte("package lib\n"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if sub := inspectSubmodule("lib"); !sub.Dirty {
+		t.Error("期望检测到子模块有未提交修改")
+	}
+}
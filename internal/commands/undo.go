@@ -0,0 +1,363 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+// gcmJournalFile gcm 日志文件名（位于安装目录下）
+const gcmJournalFile = "gcm-journal.json"
+
+// gcmJournalLimit 日志中保留的最大记录数
+const gcmJournalLimit = 200
+
+// GcmJournalEntry 一次 gcm 提交的记录
+type GcmJournalEntry struct {
+	SHA     string    `json:"sha"`
+	Branch  string    `json:"branch"`
+	Repo    string    `json:"repo"`
+	Message string    `json:"message"`
+	Pushed  bool      `json:"pushed"`
+	Undone  bool      `json:"undone"`
+	Time    time.Time `json:"time"`
+}
+
+// newUndoCommand 创建 undo 命令
+func newUndoCommand() *cobra.Command {
+	var useRevert, useForce, assumeYes bool
+
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "撤销最近一次 gcm 提交",
+		Long: `安全地撤销当前仓库中最近一次由 gcm 创建的提交。
+
+撤销策略：
+  - 未推送的提交：执行 git reset --soft，变更保留在暂存区
+  - 已推送的提交：
+      --revert  创建一个 git revert 提交并推送
+      --force   软重置后使用 --force-with-lease 推送（需要明确确认）
+    未指定时会交互式询问
+
+安全检查：
+  - 仅当 HEAD 仍是该次 gcm 提交且分支一致时才会执行
+
+示例:
+  cyber-zen undo
+  cyber-zen undo --revert
+  cyber-zen undo --force`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if useRevert && useForce {
+				return fmt.Errorf("--revert 与 --force 不能同时使用")
+			}
+			return runUndo(useRevert, useForce, assumeYes)
+		},
+	}
+
+	cmd.Flags().BoolVar(&useRevert, "revert", false, "已推送时创建 revert 提交")
+	cmd.Flags().BoolVar(&useForce, "force", false, "已推送时软重置并使用 --force-with-lease 推送")
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "跳过 revert 推送的确认（强制推送始终需要确认）")
+
+	return cmd
+}
+
+// runUndo 执行撤销
+func runUndo(useRevert, useForce, assumeYes bool) error {
+	if err := checkGitRepo(); err != nil {
+		return err
+	}
+
+	repo, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("获取仓库根目录失败: %v", err)
+	}
+
+	entries, err := loadGcmJournal()
+	if err != nil {
+		return err
+	}
+
+	index := lastUndoableEntry(entries, repo)
+	if index < 0 {
+		return fmt.Errorf("当前仓库没有可撤销的 gcm 记录")
+	}
+	entry := entries[index]
+
+	// 安全检查：HEAD 与分支必须与记录一致
+	head, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("获取 HEAD 失败: %v", err)
+	}
+	if head != entry.SHA {
+		return fmt.Errorf("HEAD (%s) 不是最近一次 gcm 提交 (%s)，为安全起见拒绝撤销", shortSHA(head), shortSHA(entry.SHA))
+	}
+	branch, _ := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if branch != entry.Branch {
+		return fmt.Errorf("当前分支 %s 与记录的分支 %s 不一致，拒绝撤销", branch, entry.Branch)
+	}
+
+	color.Cyan("最近一次 gcm 提交:")
+	fmt.Printf("  %s %s\n", shortSHA(entry.SHA), firstLine(entry.Message))
+	fmt.Printf("  分支: %s  时间: %s\n", entry.Branch, entry.Time.Local().Format("2006-01-02 15:04:05"))
+
+	// 日志可能未记录到推送（例如手动推送），以远程分支为准再确认一次
+	pushed := entry.Pushed || isCommitOnRemote(entry.SHA)
+
+	if !pushed {
+		color.Yellow("提交尚未推送，执行软重置（变更保留在暂存区）")
+		if err := softResetCommit(entry.SHA); err != nil {
+			return err
+		}
+	} else {
+		color.Yellow("⚠️  提交已推送到远程仓库")
+		if !useRevert && !useForce {
+			choice := promptUndoStrategy()
+			switch choice {
+			case "1":
+				useRevert = true
+			case "2":
+				useForce = true
+			default:
+				return fmt.Errorf("用户取消操作")
+			}
+		}
+
+		if useRevert {
+			if err := revertPushedCommit(entry, assumeYes); err != nil {
+				return err
+			}
+		} else {
+			if err := forcePushUndo(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries[index].Undone = true
+	if err := saveGcmJournal(entries); err != nil {
+		color.Yellow("⚠️  更新 gcm 日志失败: %v", err)
+	}
+
+	color.Green("✓ 撤销完成")
+	return nil
+}
+
+// promptUndoStrategy 询问已推送提交的撤销方式
+func promptUndoStrategy() string {
+	fmt.Println("请选择撤销方式:")
+	fmt.Println("  1) 创建 git revert 提交并推送（推荐，不改写历史）")
+	fmt.Println("  2) 软重置并使用 --force-with-lease 推送（改写远程历史）")
+	fmt.Println("  3) 取消")
+	fmt.Print("请输入 [1/2/3]: ")
+
+	var response string
+	fmt.Scanln(&response)
+	return strings.TrimSpace(response)
+}
+
+// softResetCommit 软重置指定提交，保留暂存区
+func softResetCommit(sha string) error {
+	// 根提交没有父提交，只能删除 HEAD 引用
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", sha+"^"); err != nil {
+		color.Yellow("执行: git update-ref -d HEAD")
+		if err := execGitCommand("update-ref", "-d", "HEAD"); err != nil {
+			return fmt.Errorf("撤销根提交失败: %v", err)
+		}
+		return nil
+	}
+
+	color.Yellow("执行: git reset --soft HEAD~1")
+	if err := execGitCommand("reset", "--soft", "HEAD~1"); err != nil {
+		return fmt.Errorf("git reset 失败: %v", err)
+	}
+	return nil
+}
+
+// revertPushedCommit 创建 revert 提交并推送
+func revertPushedCommit(entry GcmJournalEntry, assumeYes bool) error {
+	color.Yellow("执行: git revert --no-edit %s", shortSHA(entry.SHA))
+	if err := execGitCommand("revert", "--no-edit", entry.SHA); err != nil {
+		return fmt.Errorf("git revert 失败: %v", err)
+	}
+	color.Green("✓ git revert 完成")
+
+	if !assumeYes && !confirmWithUser("是否推送 revert 提交? [Y/n] ") {
+		color.Yellow("已创建 revert 提交，未推送")
+		return nil
+	}
+
+	color.Yellow("执行: git push")
+	if err := execGitCommand("push"); err != nil {
+		return fmt.Errorf("git push 失败: %v", err)
+	}
+	color.Green("✓ git push 完成")
+	return nil
+}
+
+// forcePushUndo 软重置后强制推送（带租约保护）
+func forcePushUndo(entry GcmJournalEntry) error {
+	color.Red("强制推送会改写远程分支 %s 的历史，其他协作者可能需要重新同步。", entry.Branch)
+	fmt.Printf("请输入分支名 %s 以确认: ", entry.Branch)
+
+	var response string
+	fmt.Scanln(&response)
+	if strings.TrimSpace(response) != entry.Branch {
+		return fmt.Errorf("确认失败，已取消强制推送")
+	}
+
+	if err := softResetCommit(entry.SHA); err != nil {
+		return err
+	}
+
+	// 仅当远程分支仍指向该提交时才允许覆盖
+	lease := fmt.Sprintf("--force-with-lease=%s:%s", entry.Branch, entry.SHA)
+	color.Yellow("执行: git push %s", lease)
+	if err := execGitCommand("push", lease); err != nil {
+		return fmt.Errorf("强制推送失败（本地已软重置，可用 git reset --soft %s 恢复）: %v", shortSHA(entry.SHA), err)
+	}
+	color.Green("✓ 强制推送完成")
+	return nil
+}
+
+// isCommitOnRemote 检查提交是否已存在于任意远程分支
+func isCommitOnRemote(sha string) bool {
+	output, err := gitOutput("branch", "-r", "--contains", sha)
+	return err == nil && output != ""
+}
+
+// recordGcmCommit 将当前 HEAD 记录到 gcm 日志
+func recordGcmCommit(msg string) (*GcmJournalEntry, error) {
+	sha, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("获取提交 SHA 失败: %v", err)
+	}
+	branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("获取当前分支失败: %v", err)
+	}
+	repo, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库根目录失败: %v", err)
+	}
+
+	entries, err := loadGcmJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := GcmJournalEntry{
+		SHA:     sha,
+		Branch:  branch,
+		Repo:    repo,
+		Message: msg,
+		Time:    time.Now(),
+	}
+	entries = append(entries, entry)
+	if len(entries) > gcmJournalLimit {
+		entries = entries[len(entries)-gcmJournalLimit:]
+	}
+
+	if err := saveGcmJournal(entries); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// markGcmPushed 将指定提交标记为已推送
+func markGcmPushed(sha string) error {
+	entries, err := loadGcmJournal()
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].SHA == sha {
+			entries[i].Pushed = true
+			return saveGcmJournal(entries)
+		}
+	}
+	return nil
+}
+
+// lastUndoableEntry 返回指定仓库最近一条未撤销记录的索引，没有则返回 -1
+func lastUndoableEntry(entries []GcmJournalEntry, repo string) int {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Repo == repo && !entries[i].Undone {
+			return i
+		}
+	}
+	return -1
+}
+
+// gcmJournalPath 获取 gcm 日志文件路径
+func gcmJournalPath() (string, error) {
+	installDir := config.GetInstallDir()
+	if installDir == "" {
+		return "", fmt.Errorf("安装目录未配置")
+	}
+	return filepath.Join(installDir, gcmJournalFile), nil
+}
+
+// loadGcmJournal 读取 gcm 日志
+func loadGcmJournal() ([]GcmJournalEntry, error) {
+	path, err := gcmJournalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 gcm 日志失败: %v", err)
+	}
+
+	var entries []GcmJournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析 gcm 日志失败: %v", err)
+	}
+	return entries, nil
+}
+
+// saveGcmJournal 写入 gcm 日志
+func saveGcmJournal(entries []GcmJournalEntry) error {
+	path, err := gcmJournalPath()
+	if err != nil {
+		return err
+	}
+	if err := config.EnsureInstallDir(); err != nil {
+		return fmt.Errorf("创建安装目录失败: %v", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 gcm 日志失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入 gcm 日志失败: %v", err)
+	}
+	return nil
+}
+
+// shortSHA 返回 7 位短 SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// firstLine 返回文本的第一行
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
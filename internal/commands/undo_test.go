@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/your-repo/cyben-zen-tools/internal/config"
+)
+
+// setupTestRepo 创建临时 Git 仓库并切换进去，返回仓库目录
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取当前目录失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("切换到临时目录失败: %v", err)
+	}
+
+	if err := exec.Command("git", "init").Run(); err != nil {
+		t.Skipf("跳过测试：Git 未安装或无法初始化仓库: %v", err)
+	}
+	_ = exec.Command("git", "config", "user.name", "Test User").Run()
+	_ = exec.Command("git", "config", "user.email", "test@example.com").Run()
+
+	return tempDir
+}
+
+// setupTestInstallDir 将安装目录指向临时目录
+func setupTestInstallDir(t *testing.T) {
+	t.Helper()
+
+	previous := config.GlobalConfig
+	config.GlobalConfig = &config.Config{InstallDir: t.TempDir()}
+	t.Cleanup(func() {
+		config.GlobalConfig = previous
+	})
+}
+
+func TestUndoUnpushedCommit(t *testing.T) {
+	setupTestInstallDir(t)
+	repo := setupTestRepo(t)
+
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := runGcmWithSkipPush([]string{"first"}); err != nil {
+		t.Fatalf("第一次提交失败: %v", err)
+	}
+	first, _ := gitOutput("rev-parse", "HEAD")
+
+	if err := os.WriteFile(filepath.Join(repo, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := runGcmWithSkipPush([]string{"second"}); err != nil {
+		t.Fatalf("第二次提交失败: %v", err)
+	}
+	if _, err := recordGcmCommit("second"); err != nil {
+		t.Fatalf("记录 gcm 日志失败: %v", err)
+	}
+
+	if err := runUndo(false, false, true); err != nil {
+		t.Fatalf("undo 失败: %v", err)
+	}
+
+	head, _ := gitOutput("rev-parse", "HEAD")
+	if head != first {
+		t.Errorf("期望 HEAD 回到 %s，实际为 %s", first, head)
+	}
+
+	staged, _ := gitOutput("diff", "--cached", "--name-only")
+	if staged != "b.txt" {
+		t.Errorf("期望 b.txt 保留在暂存区，实际为 %q", staged)
+	}
+
+	// 同一条记录不能被重复撤销
+	if err := runUndo(false, false, true); err == nil {
+		t.Error("期望重复撤销返回错误，但没有")
+	}
+}
+
+func TestUndoRefusesWhenHeadMoved(t *testing.T) {
+	setupTestInstallDir(t)
+	repo := setupTestRepo(t)
+
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := runGcmWithSkipPush([]string{"first"}); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+	if _, err := recordGcmCommit("first"); err != nil {
+		t.Fatalf("记录 gcm 日志失败: %v", err)
+	}
+
+	// 手动提交使 HEAD 不再指向 gcm 提交
+	if err := os.WriteFile(filepath.Join(repo, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := runGcmWithSkipPush([]string{"manual"}); err != nil {
+		t.Fatalf("提交失败: %v", err)
+	}
+
+	if err := runUndo(false, false, true); err == nil {
+		t.Error("期望 HEAD 变化后拒绝撤销，但没有")
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// categoryRule 预编译的分类规则
+type categoryRule struct {
+	Name        string
+	Description string
+	Priority    int
+	Order       int
+	Patterns    []string
+	globs       [][]string // 每个模式展开后的 glob
+}
+
+// CategoryMatch 一条命中的分类规则
+type CategoryMatch struct {
+	Category    string // 规则名，例如 test
+	Description string // 分类描述，例如 测试文件
+	Pattern     string // 配置中的模式
+	Glob        string // 实际命中的 glob
+	Priority    int
+	Order       int
+}
+
+// CategoryExplanation 文件分类的匹配过程
+type CategoryExplanation struct {
+	Path    string          // 参与匹配的规范化路径
+	Matched *CategoryMatch  // 生效的规则，nil 表示使用默认分类
+	Others  []CategoryMatch // 同样命中但优先级较低的规则
+	Default string          // 默认分类
+}
+
+// compileCategoryRules 将分类配置编译为有序规则列表
+//
+// 排序: priority 高者优先，其次 order 小者优先，最后按规则名排序，保证结果确定。
+func compileCategoryRules(patterns map[string]CategoryPattern) []categoryRule {
+	rules := make([]categoryRule, 0, len(patterns))
+	for name, pattern := range patterns {
+		rule := categoryRule{
+			Name:        name,
+			Description: pattern.Description,
+			Priority:    pattern.Priority,
+			Order:       pattern.Order,
+			Patterns:    pattern.Patterns,
+		}
+		for _, pat := range pattern.Patterns {
+			rule.globs = append(rule.globs, expandCategoryPattern(pat))
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		if rules[i].Order != rules[j].Order {
+			return rules[i].Order < rules[j].Order
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+// expandCategoryPattern 将分类模式展开为 doublestar glob
+//
+// 以 / 开头的模式锚定在仓库根目录；其余模式可以匹配任意层级的完整路径段，
+// 因此 test 匹配 test/a.go 和 pkg/test/a.go，但不匹配 internal/contest.go。
+// 模式命中目录时，目录下的所有文件都算命中。匹配不区分大小写。
+func expandCategoryPattern(pattern string) []string {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+
+	if anchored {
+		return []string{pattern, pattern + "/**"}
+	}
+	return []string{"**/" + pattern, "**/" + pattern + "/**"}
+}
+
+// normalizeMatchPath 将路径规范化为以 / 分隔的相对路径
+func normalizeMatchPath(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	path = strings.TrimPrefix(path, "./")
+	return strings.TrimPrefix(path, "/")
+}
+
+// match 返回规则中第一个命中路径的模式
+func (r categoryRule) match(path string) (CategoryMatch, bool) {
+	path = strings.ToLower(path)
+	for i, globs := range r.globs {
+		for _, glob := range globs {
+			if ok, _ := doublestar.Match(glob, path); ok {
+				return CategoryMatch{
+					Category:    r.Name,
+					Description: r.Description,
+					Pattern:     r.Patterns[i],
+					Glob:        glob,
+					Priority:    r.Priority,
+					Order:       r.Order,
+				}, true
+			}
+		}
+	}
+	return CategoryMatch{}, false
+}
+
+// GetFileCategory 获取文件分类
+func (ftm *FileTypeManager) GetFileCategory(path string) string {
+	normalized := normalizeMatchPath(path)
+	for _, rule := range ftm.categoryRules {
+		if match, ok := rule.match(normalized); ok {
+			return match.Description
+		}
+	}
+
+	// 返回默认分类
+	return ftm.categories.Default
+}
+
+// ExplainFileCategory 说明文件分类的匹配过程
+func (ftm *FileTypeManager) ExplainFileCategory(path string) CategoryExplanation {
+	explanation := CategoryExplanation{
+		Path:    normalizeMatchPath(path),
+		Default: ftm.categories.Default,
+	}
+
+	for _, rule := range ftm.categoryRules {
+		match, ok := rule.match(explanation.Path)
+		if !ok {
+			continue
+		}
+		if explanation.Matched == nil {
+			explanation.Matched = &match
+		} else {
+			explanation.Others = append(explanation.Others, match)
+		}
+	}
+	return explanation
+}
+
+// validCategoryPattern 检查分类模式是否为合法的 glob
+func validCategoryPattern(pattern string) bool {
+	for _, glob := range expandCategoryPattern(pattern) {
+		if !doublestar.ValidatePattern(glob) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package config
+
+import "testing"
+
+func newTestCategoryManager(t *testing.T, patterns map[string]interface{}) *FileTypeManager {
+	t.Helper()
+	ftm, err := newFileTypeManagerFromTree(map[string]interface{}{
+		"categories": map[string]interface{}{
+			"directory_patterns": patterns,
+			"default":            "功能模块",
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+	return ftm
+}
+
+func TestGetFileCategoryMatchesSegments(t *testing.T) {
+	ftm := newTestCategoryManager(t, map[string]interface{}{
+		"test": map[string]interface{}{
+			"priority":    100,
+			"patterns":    []interface{}{"test", "*_test.*"},
+			"description": "测试文件",
+		},
+		"docs": map[string]interface{}{
+			"priority":    90,
+			"patterns":    []interface{}{"/docs", "readme*"},
+			"description": "文档文件",
+		},
+		"source": map[string]interface{}{
+			"priority":    10,
+			"patterns":    []interface{}{"internal", "src"},
+			"description": "源代码",
+		},
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"internal/foo/contest.go", "源代码"},
+		{"internal/foo/bar_test.go", "测试文件"},
+		{"pkg/test/a.go", "测试文件"},
+		{"test", "测试文件"},
+		{"docs/guide.md", "文档文件"},
+		{"web/docs/guide.md", "功能模块"},
+		{"README.md", "文档文件"},
+		{"./src/main.go", "源代码"},
+		{"cmd/main.go", "功能模块"},
+	}
+	for _, tt := range tests {
+		if got := ftm.GetFileCategory(tt.path); got != tt.want {
+			t.Errorf("GetFileCategory(%q) = %q，期望 %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGetFileCategoryPriorityAndOrder(t *testing.T) {
+	ftm := newTestCategoryManager(t, map[string]interface{}{
+		"pages": map[string]interface{}{
+			"priority":    10,
+			"order":       2,
+			"patterns":    []interface{}{"routes"},
+			"description": "页面文件",
+		},
+		"api": map[string]interface{}{
+			"priority":    10,
+			"order":       1,
+			"patterns":    []interface{}{"routes"},
+			"description": "API 接口",
+		},
+		"zeta": map[string]interface{}{
+			"patterns":    []interface{}{"shared"},
+			"description": "Z",
+		},
+		"alpha": map[string]interface{}{
+			"patterns":    []interface{}{"shared"},
+			"description": "A",
+		},
+	})
+
+	// 多次执行结果必须一致（不依赖 map 遍历顺序）
+	for i := 0; i < 20; i++ {
+		if got := ftm.GetFileCategory("src/routes/user.ts"); got != "API 接口" {
+			t.Fatalf("期望 order 小的规则优先，实际为 %q", got)
+		}
+		if got := ftm.GetFileCategory("shared/x.go"); got != "A" {
+			t.Fatalf("期望同优先级同顺序时按规则名排序，实际为 %q", got)
+		}
+	}
+
+	explanation := ftm.ExplainFileCategory("src/routes/user.ts")
+	if explanation.Matched == nil || explanation.Matched.Category != "api" || explanation.Matched.Pattern != "routes" {
+		t.Fatalf("期望命中 api 规则，实际为 %+v", explanation.Matched)
+	}
+	if len(explanation.Others) != 1 || explanation.Others[0].Category != "pages" {
+		t.Errorf("期望 pages 为被覆盖的候选规则，实际为 %+v", explanation.Others)
+	}
+
+	if explanation := ftm.ExplainFileCategory("cmd/main.go"); explanation.Matched != nil || explanation.Default != "功能模块" {
+		t.Errorf("期望未命中时使用默认分类，实际为 %+v", explanation)
+	}
+}
+
+func TestValidCategoryPattern(t *testing.T) {
+	for _, pattern := range []string{"test", "/docs", "*_test.*", "**/e2e/**", "{api,rpc}"} {
+		if !validCategoryPattern(pattern) {
+			t.Errorf("期望 %q 是合法模式", pattern)
+		}
+	}
+	if validCategoryPattern("[abc") {
+		t.Error("期望 [abc 不是合法模式")
+	}
+}
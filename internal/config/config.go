@@ -3,18 +3,85 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// EnvPrefix 环境变量前缀，配置键中的 . 替换为 _，例如 compress.rate 对应 CYBER_ZEN_COMPRESS_RATE
+const EnvPrefix = "CYBER_ZEN"
+
 // Config 应用配置结构
 type Config struct {
+	// Version 配置格式版本，见 CurrentConfigVersion
+	Version       int    `mapstructure:"version" schema:"minimum=1"`
 	InstallDir    string `mapstructure:"install_dir"`
 	Platform      string `mapstructure:"platform"`
 	Architecture  string `mapstructure:"architecture"`
+	ConfigDir     string `mapstructure:"config_dir"`
+	Language      string `mapstructure:"language" schema:"enum=zh|en"`
+	// Profile 使用的配置档案，Profiles 为 config.yaml 中定义的所有档案
+	Profile       string                 `mapstructure:"profile"`
+	Profiles      map[string]interface{} `mapstructure:"profiles"`
+	Team          map[string]TeamMember `mapstructure:"team"`
+	// ProtectedBranches 受保护分支（支持通配符），gcm 不会直接提交到这些分支
+	ProtectedBranches []string       `mapstructure:"protected_branches" schema:"pattern=\\S,message=值不能为空"`
+	Compress          CompressConfig `mapstructure:"compress"`
+	Gcm               GcmConfig      `mapstructure:"gcm"`
+	Server            ServerConfig   `mapstructure:"server"`
+}
+
+// ByteSizePattern 文件大小的正则（不含首尾锚点），例如 300KB、1.5MB、1GB、2048
+//
+// 第 1 组为数值，第 2 组为单位；compress --target-size 的解析和 config schema 共用，避免两者不一致。
+const ByteSizePattern = `([0-9]+(?:\.[0-9]+)?)\s*([kKmMgG]?[bB]|[kKmMgG])?`
+
+// CompressConfig 图片压缩默认值
+type CompressConfig struct {
+	Rate float64 `mapstructure:"rate" yaml:"rate" schema:"minimum=0.1,maximum=1"`
+	Dist string  `mapstructure:"dist" yaml:"dist"`
+	// Filter 缩放使用的重采样滤波器
+	Filter string `mapstructure:"filter" yaml:"filter" schema:"enum=nearest|box|bilinear|catmull-rom|lanczos3"`
+	// TargetSize 单个文件的目标大小，例如 300KB，为空表示不限制
+	TargetSize string `mapstructure:"target_size" yaml:"target_size" schema:"pattern=@byte_size,message=应为文件大小，例如 300KB、1.5MB、1GB"`
+	// Format 输出格式，为空时与源文件相同
+	Format string `mapstructure:"format" yaml:"format" schema:"enum=jpeg|png|gif"`
+	// Background 转换为 JPEG 时透明区域的背景色
+	Background string `mapstructure:"background" yaml:"background" schema:"pattern=^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$,message=应为十六进制颜色，例如 #ffffff"`
+	// Metadata 元数据处理方式：strip 全部移除，keep 全部保留，keep-safe 保留色彩配置和版权、移除 GPS 位置
+	Metadata string `mapstructure:"metadata" yaml:"metadata" schema:"enum=strip|keep|keep-safe"`
+	// MinSavings 重新编码至少节省的百分比，不足时保留原文件内容
+	MinSavings float64 `mapstructure:"min_savings" yaml:"min_savings" schema:"minimum=0,exclusiveMaximum=100"`
+}
+
+// GcmConfig gcm 默认值
+type GcmConfig struct {
+	Push              bool     `mapstructure:"push" yaml:"push"`
+	RecurseSubmodules bool     `mapstructure:"recurse_submodules" yaml:"recurse_submodules"`
+	With              []string `mapstructure:"with" yaml:"with" schema:"pattern=\\S,message=值不能为空"`
+}
+
+// ServerConfig 静态文件服务器默认值
+type ServerConfig struct {
+	Host string `mapstructure:"host" yaml:"host"`
+	Port int    `mapstructure:"port" yaml:"port" schema:"minimum=1,maximum=65535"`
+}
+
+// repoSettingKeys 可以由仓库 .cyber-zen.yaml 覆盖的应用设置
+var repoSettingKeys = []string{"protected_branches", "compress", "gcm", "server"}
+
+// boundFlags 已绑定到配置键的全局标志
+var boundFlags = make(map[string]*pflag.Flag)
+
+// TeamMember 团队成员（用于 Co-authored-by 署名）
+type TeamMember struct {
+	Name  string `mapstructure:"name"`
+	Email string `mapstructure:"email"`
 }
 
 var (
@@ -22,6 +89,8 @@ var (
 	GlobalConfig *Config
 	// AppName 应用名称
 	AppName = "cyben-zen-tools"
+	// settingsFile 用户设置文件 config.yaml 的路径（可能尚不存在）
+	settingsFile string
 )
 
 // Init 初始化配置
@@ -35,35 +104,106 @@ func Init() error {
 	// 设置默认安装目录
 	defaultInstallDir := filepath.Join(home, ".cyben-zen-tools")
 
-	// 设置配置文件名
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(defaultInstallDir)
-	viper.AddConfigPath(".")
+	// 优先级: 命令行标志 > CYBER_ZEN_* 环境变量 > 仓库 .cyber-zen.yaml > 配置档案 > config.yaml > 默认值
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 
 	// 设置默认值
 	viper.SetDefault("install_dir", defaultInstallDir)
+	viper.SetDefault("config_dir", filepath.Join(home, ".cyber-zen", "configs"))
+	viper.SetDefault("language", "zh")
+	viper.SetDefault("profile", "")
 	viper.SetDefault("platform", runtime.GOOS)
 	viper.SetDefault("architecture", runtime.GOARCH)
+	viper.SetDefault("protected_branches", []string{})
+	viper.SetDefault("compress.rate", 0.8)
+	viper.SetDefault("compress.dist", "")
+	viper.SetDefault("compress.filter", "catmull-rom")
+	viper.SetDefault("compress.target_size", "")
+	viper.SetDefault("compress.format", "")
+	viper.SetDefault("compress.background", "#ffffff")
+	viper.SetDefault("compress.metadata", "strip")
+	viper.SetDefault("compress.min_savings", 5.0)
+	viper.SetDefault("gcm.push", true)
+	viper.SetDefault("gcm.recurse_submodules", false)
+	viper.SetDefault("gcm.with", []string{})
+	viper.SetDefault("server.host", "")
+	viper.SetDefault("server.port", 3000)
+
+	// 设置配置文件名，config.yaml 位于安装目录（可由环境变量或标志覆盖）
+	viper.SetConfigName("config")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath(viper.GetString("install_dir"))
+	viper.AddConfigPath(".")
 
-	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return err
+	// 读取配置文件；解析失败时也记录路径，供 config validate、config edit 使用
+	readErr := viper.ReadInConfig()
+	settingsFile = viper.ConfigFileUsed()
+	if settingsFile == "" {
+		settingsFile = filepath.Join(viper.GetString("install_dir"), "config.yaml")
+	}
+	if readErr != nil {
+		if _, ok := readErr.(viper.ConfigFileNotFoundError); !ok {
+			return readErr
 		}
 	}
 
+	// 按 config schema 中的规则校验 config.yaml，问题定位到行号
+	// 只输出警告，避免一处笔误导致所有命令（包括 config edit、config validate）都无法运行
+	if _, err := os.Stat(settingsFile); err == nil {
+		if issues := ValidateConfigFile(settingsFile); len(issues) > 0 {
+			WarningHandler(fmt.Sprintf("%v\n运行 cyber-zen config validate 查看详情，或 cyber-zen config edit 修改", &ValidationError{Issues: issues}))
+		}
+	}
+
+	// 应用命名配置档案，优先级高于 config.yaml、低于仓库 .cyber-zen.yaml
+	// 档案中非法的键已在上面的校验中报告，这里直接忽略
+	profiles, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	dropInvalidProfileKeys(profiles)
+	if activeProfile, err = resolveProfile(".", profiles); err != nil {
+		return err
+	}
+	if err := applyProfileSettings(activeProfile); err != nil {
+		return err
+	}
+
+	// 合并仓库及子目录中的 .cyber-zen.yaml
+	if err := mergeRepoSettings("."); err != nil {
+		return err
+	}
+
 	// 解析配置到结构体
 	GlobalConfig = &Config{}
 	if err := viper.Unmarshal(GlobalConfig); err != nil {
 		return err
 	}
 
-
+	if GlobalConfig.Language != "zh" && GlobalConfig.Language != "en" {
+		WarningHandler(fmt.Sprintf("不支持的语言: %s（可选 zh、en），使用 zh", GlobalConfig.Language))
+		GlobalConfig.Language = "zh"
+	}
 
 	return nil
 }
 
+// BindFlag 将命令行标志绑定到配置键，显式指定的标志优先于环境变量和配置文件
+func BindFlag(key string, flag *pflag.Flag) error {
+	if flag == nil {
+		return fmt.Errorf("标志不存在: %s", key)
+	}
+	boundFlags[key] = flag
+	return viper.BindPFlag(key, flag)
+}
+
+// EnvName 返回配置键对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
 // GetInstallDir 获取安装目录
 func GetInstallDir() string {
 	if GlobalConfig != nil {
@@ -74,6 +214,91 @@ func GetInstallDir() string {
 
 
 
+// GetLanguage 获取生成提交信息使用的语言（zh 或 en）
+func GetLanguage() string {
+	if GlobalConfig != nil && GlobalConfig.Language != "" {
+		return GlobalConfig.Language
+	}
+	return "zh"
+}
+
+// GetGcmConfig 获取 gcm 默认值
+func GetGcmConfig() GcmConfig {
+	if GlobalConfig != nil {
+		return GlobalConfig.Gcm
+	}
+	return GcmConfig{Push: true}
+}
+
+// GetServerConfig 获取静态文件服务器默认值
+func GetServerConfig() ServerConfig {
+	if GlobalConfig != nil {
+		return GlobalConfig.Server
+	}
+	return ServerConfig{Port: 3000}
+}
+
+// GetSettingsFile 获取用户设置文件 config.yaml 的路径
+func GetSettingsFile() string {
+	return settingsFile
+}
+
+// GetTeamMember 根据别名获取团队成员
+func GetTeamMember(alias string) (TeamMember, bool) {
+	if GlobalConfig == nil {
+		return TeamMember{}, false
+	}
+	member, ok := GlobalConfig.Team[strings.ToLower(alias)]
+	return member, ok
+}
+
+// mergeRepoSettings 将仓库配置中的应用设置合并到 viper
+func mergeRepoSettings(dir string) error {
+	for _, path := range FindRepoConfigFiles(dir) {
+		tree, err := loadYAMLTree(path)
+		if err != nil {
+			// 语法错误由 config validate 定位，这里跳过该文件，不影响其他命令
+			WarningHandler(fmt.Sprintf("%v，已忽略该文件", err))
+			continue
+		}
+
+		settings := make(map[string]interface{})
+		for _, key := range repoSettingKeys {
+			if value, ok := tree[key]; ok {
+				settings[key] = value
+			}
+		}
+		if len(settings) == 0 {
+			continue
+		}
+		if err := viper.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("合并仓库配置失败 %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// IsProtectedBranch 判断分支是否受保护
+func IsProtectedBranch(branch string) bool {
+	if GlobalConfig == nil {
+		return false
+	}
+	for _, pattern := range GlobalConfig.ProtectedBranches {
+		if matched, _ := path.Match(pattern, branch); matched || pattern == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCompressConfig 获取图片压缩默认值
+func GetCompressConfig() CompressConfig {
+	if GlobalConfig != nil {
+		return GlobalConfig.Compress
+	}
+	return CompressConfig{Rate: 0.8, Filter: "catmull-rom", Background: "#ffffff", Metadata: "strip", MinSavings: 5}
+}
+
 // GetPlatform 获取平台信息
 func GetPlatform() string {
 	if GlobalConfig != nil {
@@ -4,6 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 func TestConfigInit(t *testing.T) {
@@ -104,4 +107,119 @@ func TestEnsureInstallDirEmpty(t *testing.T) {
 	if err == nil {
 		t.Error("期望返回错误，但没有")
 	}
-} 
\ No newline at end of file
+} 
+
+func TestIsProtectedBranch(t *testing.T) {
+	previous := GlobalConfig
+	t.Cleanup(func() {
+		GlobalConfig = previous
+	})
+	GlobalConfig = &Config{
+		ProtectedBranches: []string{"main", "release/*"},
+	}
+
+	tests := map[string]bool{
+		"main":          true,
+		"release/1.0":   true,
+		"feature/login": false,
+		"mainline":      false,
+	}
+	for branch, expected := range tests {
+		if IsProtectedBranch(branch) != expected {
+			t.Errorf("分支 %s 期望受保护=%v", branch, expected)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	defer func() {
+		GlobalConfig = nil
+	}()
+
+	t.Setenv("CYBER_ZEN_COMPRESS_RATE", "0.5")
+	t.Setenv("CYBER_ZEN_GCM_PUSH", "false")
+	t.Setenv("CYBER_ZEN_GCM_WITH", "alice,bob")
+	t.Setenv("CYBER_ZEN_SERVER_PORT", "8080")
+	t.Setenv("CYBER_ZEN_LANGUAGE", "en")
+
+	if err := Init(); err != nil {
+		t.Fatalf("配置初始化失败: %v", err)
+	}
+
+	if rate := GetCompressConfig().Rate; rate != 0.5 {
+		t.Errorf("期望环境变量覆盖 compress.rate=0.5，实际为 %v", rate)
+	}
+	gcm := GetGcmConfig()
+	if gcm.Push {
+		t.Error("期望环境变量覆盖 gcm.push=false")
+	}
+	if len(gcm.With) != 2 || gcm.With[0] != "alice" || gcm.With[1] != "bob" {
+		t.Errorf("期望 gcm.with=[alice bob]，实际为 %v", gcm.With)
+	}
+	if port := GetServerConfig().Port; port != 8080 {
+		t.Errorf("期望 server.port=8080，实际为 %d", port)
+	}
+	if language := GetLanguage(); language != "en" {
+		t.Errorf("期望 language=en，实际为 %s", language)
+	}
+}
+
+func TestFlagOverridesEnv(t *testing.T) {
+	defer func() {
+		GlobalConfig = nil
+	}()
+
+	// 标志绑定是全局的，测试结束后重置，避免影响其他测试
+	t.Cleanup(func() {
+		viper.Reset()
+		delete(boundFlags, "server.port")
+	})
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int("server-port", 0, "")
+	if err := BindFlag("server.port", flags.Lookup("server-port")); err != nil {
+		t.Fatalf("绑定标志失败: %v", err)
+	}
+	t.Setenv("CYBER_ZEN_SERVER_PORT", "8080")
+
+	// 未显式指定标志时使用环境变量
+	if err := Init(); err != nil {
+		t.Fatalf("配置初始化失败: %v", err)
+	}
+	if port := GetServerConfig().Port; port != 8080 {
+		t.Errorf("期望未指定标志时使用环境变量 8080，实际为 %d", port)
+	}
+
+	if err := flags.Parse([]string{"--server-port", "9090"}); err != nil {
+		t.Fatalf("解析标志失败: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("配置初始化失败: %v", err)
+	}
+	if port := GetServerConfig().Port; port != 9090 {
+		t.Errorf("期望标志优先于环境变量 9090，实际为 %d", port)
+	}
+
+	values, err := EffectiveValues(t.TempDir())
+	if err != nil {
+		t.Fatalf("EffectiveValues 失败: %v", err)
+	}
+	for _, value := range values {
+		if value.Key == "server.port" && (value.Scope != ScopeFlag || value.Source != "--server-port") {
+			t.Errorf("期望 server.port 来自 --server-port 标志，实际为 %+v", value)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"install_dir":            "CYBER_ZEN_INSTALL_DIR",
+		"compress.rate":          "CYBER_ZEN_COMPRESS_RATE",
+		"gcm.recurse_submodules": "CYBER_ZEN_GCM_RECURSE_SUBMODULES",
+	}
+	for key, want := range tests {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%s) = %s，期望 %s", key, got, want)
+		}
+	}
+}
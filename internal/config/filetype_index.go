@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// unknownFileType 无法识别时的文件类型描述
+const unknownFileType = "其他文件"
+
+// shebangReadLimit 读取 shebang 时最多读取的字节数
+const shebangReadLimit = 256
+
+// fileTypeGlob 文件名 glob 规则
+type fileTypeGlob struct {
+	Pattern     string
+	Description string
+}
+
+// fileTypeIndex 预编译的文件类型索引，在创建 FileTypeManager 时构建一次
+//
+// 同一个文件名、扩展名或解释器出现在多个类型中时，手写条目优先于导入的 Linguist 条目，
+// 其次按 分类名.类型名 的字母序取第一个，保证结果确定。
+type fileTypeIndex struct {
+	filenames     map[string]string // 小写完整文件名 -> 描述
+	filenameGlobs []fileTypeGlob    // 含通配符的文件名模式
+	suffixes      map[string]string // 小写扩展名（含 .）-> 描述
+	interpreters  map[string]string // shebang 解释器 -> 描述
+}
+
+// newFileTypeIndex 根据文件类型配置构建索引
+func newFileTypeIndex(config *FileTypeConfig) *fileTypeIndex {
+	index := &fileTypeIndex{
+		filenames:    make(map[string]string),
+		suffixes:     make(map[string]string),
+		interpreters: make(map[string]string),
+	}
+
+	// 第一遍加入手写条目，第二遍加入导入的条目
+	for _, imported := range []bool{false, true} {
+		for _, categoryName := range sortedKeys(config.FileTypes) {
+			category := config.FileTypes[categoryName]
+			for _, typeName := range sortedKeys(category) {
+				item := category[typeName]
+				if (item.Source == LinguistSource) != imported {
+					continue
+				}
+				index.add(item)
+			}
+		}
+	}
+	return index
+}
+
+// add 将一个文件类型的规则加入索引
+func (index *fileTypeIndex) add(item FileTypeItem) {
+	for _, name := range item.Filenames {
+		name = strings.ToLower(name)
+		if strings.ContainsAny(name, "*?[{") {
+			index.filenameGlobs = append(index.filenameGlobs, fileTypeGlob{Pattern: name, Description: item.Description})
+			continue
+		}
+		addIndexEntry(index.filenames, name, item.Description)
+	}
+	for _, ext := range item.Extensions {
+		addIndexEntry(index.suffixes, strings.ToLower(ext), item.Description)
+	}
+	for _, interpreter := range item.Shebangs {
+		addIndexEntry(index.interpreters, interpreter, item.Description)
+	}
+}
+
+// addIndexEntry 只在键不存在时写入，先出现的类型优先
+func addIndexEntry(m map[string]string, key, description string) {
+	if _, ok := m[key]; !ok {
+		m[key] = description
+	}
+}
+
+// lookup 按 完整文件名 > 文件名 glob > 最长扩展名 的顺序识别文件类型
+func (index *fileTypeIndex) lookup(filename string) (string, bool) {
+	base := strings.ToLower(path.Base(filepath.ToSlash(filename)))
+
+	if description, ok := index.filenames[base]; ok {
+		return description, true
+	}
+	for _, glob := range index.filenameGlobs {
+		if ok, _ := doublestar.Match(glob.Pattern, base); ok {
+			return glob.Description, true
+		}
+	}
+
+	// 从第一个 . 开始依次尝试，越靠前的 . 对应的扩展名越长，例如 .d.ts 优先于 .ts
+	for i := 0; i < len(base); i++ {
+		if base[i] != '.' {
+			continue
+		}
+		if description, ok := index.suffixes[base[i:]]; ok {
+			return description, true
+		}
+	}
+	return "", false
+}
+
+// lookupShebang 根据文件首行的 shebang 识别脚本类型
+func (index *fileTypeIndex) lookupShebang(filename string) (string, bool) {
+	interpreter := readShebangInterpreter(filename)
+	if interpreter == "" {
+		return "", false
+	}
+	if description, ok := index.interpreters[interpreter]; ok {
+		return description, true
+	}
+	// python3.11 -> python3 -> python
+	trimmed := strings.TrimRight(interpreter, "0123456789.")
+	if description, ok := index.interpreters[trimmed]; ok {
+		return description, true
+	}
+	return "", false
+}
+
+// readShebangInterpreter 读取文件 shebang 中的解释器名，例如
+// "#!/usr/bin/env -S python3 -u" 返回 python3，"#!/bin/bash" 返回 bash
+func readShebangInterpreter(filename string) string {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReaderSize(file, shebangReadLimit).Peek(shebangReadLimit)
+	if !strings.HasPrefix(string(line), "#!") {
+		return ""
+	}
+	firstLine := strings.SplitN(string(line[2:]), "\n", 2)[0]
+	fields := strings.Fields(firstLine)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// 跳过 env 的参数和环境变量赋值
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = path.Base(field)
+			break
+		}
+	}
+	return interpreter
+}
+
+// GetFileType 获取文件类型描述
+//
+// 识别顺序: 完整文件名（Dockerfile、go.mod）> 文件名 glob > 最长扩展名（.d.ts 优先于 .ts）
+// > shebang（仅当文件存在且可读时）。
+func (ftm *FileTypeManager) GetFileType(filename string) string {
+	if description, ok := ftm.typeIndex.lookup(filename); ok {
+		return description
+	}
+	if description, ok := ftm.typeIndex.lookupShebang(filename); ok {
+		return description
+	}
+	return unknownFileType
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestFileTypeManager(t *testing.T) *FileTypeManager {
+	t.Helper()
+	ftm, err := newFileTypeManagerFromTree(map[string]interface{}{
+		"file_types": map[string]interface{}{
+			"frontend": map[string]interface{}{
+				"typescript": map[string]interface{}{
+					"extensions":  []interface{}{".ts"},
+					"description": "TypeScript 文件",
+				},
+				"declaration": map[string]interface{}{
+					"extensions":  []interface{}{".d.ts"},
+					"description": "TypeScript 类型声明",
+				},
+			},
+			"backend": map[string]interface{}{
+				"python": map[string]interface{}{
+					"extensions":  []interface{}{".py"},
+					"shebangs":    []interface{}{"python"},
+					"description": "Python 代码",
+				},
+			},
+			"build": map[string]interface{}{
+				"build": map[string]interface{}{
+					"filenames":   []interface{}{"Makefile"},
+					"description": "构建配置文件",
+				},
+				"deployment": map[string]interface{}{
+					"filenames":   []interface{}{"Dockerfile", "Dockerfile.*"},
+					"description": "部署配置文件",
+				},
+				"dependencies": map[string]interface{}{
+					"extensions":  []interface{}{".mod"},
+					"filenames":   []interface{}{"go.mod"},
+					"description": "依赖管理文件",
+				},
+			},
+			"docs": map[string]interface{}{
+				"scripts": map[string]interface{}{
+					"extensions":  []interface{}{".sh"},
+					"filenames":   []interface{}{".bashrc"},
+					"shebangs":    []interface{}{"bash", "sh"},
+					"description": "脚本文件",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+	return ftm
+}
+
+func TestGetFileTypeByName(t *testing.T) {
+	ftm := newTestFileTypeManager(t)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"Dockerfile", "部署配置文件"},
+		{"deploy/Dockerfile.dev", "部署配置文件"},
+		{"Makefile", "构建配置文件"},
+		{"go.mod", "依赖管理文件"},
+		{"home/.bashrc", "脚本文件"},
+		{"src/types/index.d.ts", "TypeScript 类型声明"},
+		{"src/index.ts", "TypeScript 文件"},
+		{"scripts/build.SH", "脚本文件"},
+		{"notes", "其他文件"},
+	}
+	for _, tt := range tests {
+		if got := ftm.GetFileType(tt.path); got != tt.want {
+			t.Errorf("GetFileType(%q) = %q，期望 %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGetFileTypeByShebang(t *testing.T) {
+	ftm := newTestFileTypeManager(t)
+	dir := t.TempDir()
+
+	scripts := map[string]string{
+		"deploy":  "#!/bin/bash\nset -e\n",
+		"manage":  "#!/usr/bin/env -S python3.11 -u\nprint('hi')\n",
+		"plain":   "just text\n",
+		"envvars": "#!/usr/bin/env LANG=C sh\n",
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("写入脚本失败: %v", err)
+		}
+	}
+
+	tests := map[string]string{
+		"deploy":  "脚本文件",
+		"manage":  "Python 代码",
+		"plain":   "其他文件",
+		"envvars": "脚本文件",
+		"missing": "其他文件",
+	}
+	for name, want := range tests {
+		if got := ftm.GetFileType(filepath.Join(dir, name)); got != want {
+			t.Errorf("GetFileType(%q) = %q，期望 %q", name, got, want)
+		}
+	}
+}
+
+func TestReadShebangInterpreter(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"#!/bin/sh\n":                     "sh",
+		"#! /usr/bin/env node\n":          "node",
+		"#!/usr/bin/env -S deno run -A\n": "deno",
+		"#!\n":                            "",
+		"echo hi\n":                       "",
+	}
+	i := 0
+	for content, want := range tests {
+		path := filepath.Join(dir, filepath.Base(t.Name())+string(rune('a'+i)))
+		i++
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("写入文件失败: %v", err)
+		}
+		if got := readShebangInterpreter(path); got != want {
+			t.Errorf("readShebangInterpreter(%q) = %q，期望 %q", content, got, want)
+		}
+	}
+}
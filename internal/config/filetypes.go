@@ -2,17 +2,17 @@ package config
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 // FileTypeItem 文件类型项
 type FileTypeItem struct {
-	Extensions []string `yaml:"extensions"`
-	Description string  `yaml:"description"`
+	Extensions  []string `yaml:"extensions,omitempty" schema:"pattern=^\\.,message=应以 . 开头"`
+	Filenames   []string `yaml:"filenames,omitempty" schema:"pattern=\\S,message=值不能为空"` // 完整文件名或文件名 glob，例如 Dockerfile、go.mod、Dockerfile.*
+	Shebangs    []string `yaml:"shebangs,omitempty" schema:"pattern=\\S,message=值不能为空"`  // shebang 解释器，例如 bash、python3
+	Description string   `yaml:"description" schema:"pattern=\\S,message=值不能为空"`
+	Source      string   `yaml:"source,omitempty"` // 来源标记，linguist 表示由 config import-linguist 导入
 }
 
 // FileTypeCategory 文件类型分类
@@ -20,19 +20,31 @@ type FileTypeCategory map[string]FileTypeItem
 
 // FileTypeConfig 文件类型配置结构
 type FileTypeConfig struct {
+	Version   int                         `yaml:"version,omitempty" schema:"minimum=1"`
 	FileTypes map[string]FileTypeCategory `yaml:"file_types"`
 }
 
 // CategoryPattern 分类模式
+//
+// Patterns 支持 doublestar glob（**、*、?、[...]、{a,b}），以 / 开头时锚定仓库根目录。
+// 多条规则同时命中时 Priority 高者优先，其次 Order 小者优先。
 type CategoryPattern struct {
-	Patterns   []string `yaml:"patterns"`
-	Description string  `yaml:"description"`
+	Patterns   []string `yaml:"patterns" schema:"pattern=\\S,format=glob,message=值不能为空"`
+	Description string  `yaml:"description" schema:"pattern=\\S,message=值不能为空"`
+	Priority    int     `yaml:"priority"`
+	Order       int     `yaml:"order"`
 }
 
 // CategoryConfig 文件分类配置结构
 type CategoryConfig struct {
 	DirectoryPatterns map[string]CategoryPattern `yaml:"directory_patterns"`
-	Default           string                     `yaml:"default"`
+	Default           string                     `yaml:"default" schema:"pattern=\\S,message=值不能为空"`
+}
+
+// CategoryFile categories.yaml 文件结构
+type CategoryFile struct {
+	Version    int            `yaml:"version,omitempty" schema:"minimum=1"`
+	Categories CategoryConfig `yaml:"categories"`
 }
 
 // CommitTemplateConfig Commit 模板配置结构
@@ -40,6 +52,36 @@ type CommitTemplateConfig struct {
 	Prefixes    map[string]string            `yaml:"prefixes"`
 	Descriptions map[string]string           `yaml:"descriptions"`
 	Actions     map[string]string            `yaml:"actions"`
+	SummaryTemplates SummaryTemplates         `yaml:"summary_templates"`
+	DetailTemplate   string                   `yaml:"detail_template"`
+	ChangeTypeRules  map[string]ChangeTypeRule `yaml:"change_type_rules"`
+	FileTypeWeights  map[string]FileTypeWeight `yaml:"file_type_weights"`
+}
+
+// SummaryTemplates 摘要模板
+type SummaryTemplates struct {
+	SingleFile    map[string]string `yaml:"single_file"`
+	MultipleFiles map[string]string `yaml:"multiple_files"`
+	Special       map[string]string `yaml:"special"`
+}
+
+// ChangeTypeRule 变更类型判断规则
+type ChangeTypeRule struct {
+	Conditions  []string `yaml:"conditions"`
+	Type        string   `yaml:"type"`
+	Description string   `yaml:"description"`
+}
+
+// FileTypeWeight 文件分类权重
+type FileTypeWeight struct {
+	Categories []string `yaml:"categories"`
+	Weight     int      `yaml:"weight"`
+}
+
+// CommitTemplateFile commit-templates.yaml 文件结构
+type CommitTemplateFile struct {
+	Version         int                  `yaml:"version,omitempty" schema:"minimum=1"`
+	CommitTemplates CommitTemplateConfig `yaml:"commit_templates"`
 }
 
 // FileTypeManager 文件类型管理器
@@ -47,139 +89,121 @@ type FileTypeManager struct {
 	fileTypes     *FileTypeConfig
 	categories    *CategoryConfig
 	commitTemplates *CommitTemplateConfig
+	categoryRules   []categoryRule
+	typeIndex       *fileTypeIndex
 }
 
 // NewFileTypeManager 创建文件类型管理器
+//
+// 配置按 内置默认 < 用户目录 < 仓库 .cyber-zen.yaml 的顺序深度合并。
+// 任何配置文件存在未知字段、类型错误或取值错误时返回 *ValidationError。
 func NewFileTypeManager() (*FileTypeManager, error) {
-	// 获取配置文件路径
-	configDir := getConfigDir()
-	
-	// 读取文件类型配置
-	fileTypes, err := loadFileTypeConfig(configDir)
-	if err != nil {
-		return nil, fmt.Errorf("加载文件类型配置失败: %v", err)
-	}
-	
-	// 读取分类配置
-	categories, err := loadCategoryConfig(configDir)
+	return NewFileTypeManagerForDir(".")
+}
+
+// NewFileTypeManagerForDir 创建 dir 下生效的文件类型管理器
+//
+// 与 NewFileTypeManager 相同，但合并的是仓库根目录到 dir 路径上的 .cyber-zen.yaml。
+func NewFileTypeManagerForDir(dir string) (*FileTypeManager, error) {
+	ftm, issues, err := loadValidatedFileTypeManager(dir)
 	if err != nil {
-		return nil, fmt.Errorf("加载分类配置失败: %v", err)
+		return nil, err
 	}
-	
-	// 读取 commit 模板配置
-	commitTemplates, err := loadCommitTemplateConfig(configDir)
-	if err != nil {
-		return nil, fmt.Errorf("加载 commit 模板配置失败: %v", err)
+	if len(issues) > 0 {
+		return nil, &ValidationError{Issues: issues}
 	}
-	
-	return &FileTypeManager{
-		fileTypes:     fileTypes,
-		categories:    categories,
-		commitTemplates: commitTemplates,
-	}, nil
+	return ftm, nil
+}
+
+// DirFileTypeManagers 按文件所在目录提供文件类型管理器
+//
+// 子目录的 .cyber-zen.yaml 只作用于该目录下的文件，与运行命令的目录无关。
+// 生效的 .cyber-zen.yaml 相同的目录共用一个管理器。
+type DirFileTypeManagers struct {
+	managers map[string]*FileTypeManager // 键为生效的 .cyber-zen.yaml 路径列表
 }
 
-// getConfigDir 获取配置文件目录
-func getConfigDir() string {
-	// 优先使用当前目录的配置
-	if _, err := os.Stat("configs"); err == nil {
-		return "configs"
+// NewDirFileTypeManagers 创建按目录缓存的文件类型管理器集合
+func NewDirFileTypeManagers() *DirFileTypeManagers {
+	return &DirFileTypeManagers{managers: make(map[string]*FileTypeManager)}
+}
+
+// ForFile 返回 path 所在目录生效的文件类型管理器，path 可以是已删除的文件
+func (m *DirFileTypeManagers) ForFile(path string) (*FileTypeManager, error) {
+	dir := filepath.Dir(path)
+	key := strings.Join(FindRepoConfigFiles(dir), "\n")
+	if ftm, ok := m.managers[key]; ok {
+		return ftm, nil
 	}
-	
-	// 使用可执行文件同目录的配置
-	exe, _ := os.Executable()
-	exeDir := filepath.Dir(exe)
-	configPath := filepath.Join(exeDir, "configs")
-	
-	if _, err := os.Stat(configPath); err == nil {
-		return configPath
+	ftm, err := NewFileTypeManagerForDir(dir)
+	if err != nil {
+		return nil, err
 	}
-	
-	// 使用用户主目录的配置
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".cyber-zen", "configs")
+	m.managers[key] = ftm
+	return ftm, nil
 }
 
-// loadFileTypeConfig 加载文件类型配置
-func loadFileTypeConfig(configDir string) (*FileTypeConfig, error) {
-	configPath := filepath.Join(configDir, "file-types.yaml")
-	
-	data, err := os.ReadFile(configPath)
+// ValidateEffectiveConfig 校验 dir 下生效的所有配置层以及合并结果
+func ValidateEffectiveConfig(dir string) ([]ConfigLayer, []ValidationIssue, error) {
+	layers, err := LoadConfigLayers(dir)
 	if err != nil {
-		return nil, fmt.Errorf("读取文件类型配置文件失败: %v", err)
+		return nil, nil, err
 	}
-	
-	var config FileTypeConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析文件类型配置文件失败: %v", err)
-	}
-	
-	return &config, nil
+	_, issues, err := validatedManagerFromLayers(layers)
+	return layers, issues, err
 }
 
-// loadCategoryConfig 加载分类配置
-func loadCategoryConfig(configDir string) (*CategoryConfig, error) {
-	configPath := filepath.Join(configDir, "categories.yaml")
-	
-	data, err := os.ReadFile(configPath)
+// loadValidatedFileTypeManager 加载、校验并创建文件类型管理器
+func loadValidatedFileTypeManager(dir string) (*FileTypeManager, []ValidationIssue, error) {
+	layers, err := LoadConfigLayers(dir)
 	if err != nil {
-		return nil, fmt.Errorf("读取分类配置文件失败: %v", err)
+		return nil, nil, err
 	}
-	
-	var config CategoryConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析分类配置文件失败: %v", err)
-	}
-	
-	return &config, nil
+	return validatedManagerFromLayers(layers)
 }
 
-// loadCommitTemplateConfig 加载 commit 模板配置
-func loadCommitTemplateConfig(configDir string) (*CommitTemplateConfig, error) {
-	configPath := filepath.Join(configDir, "commit-templates.yaml")
-	
-	data, err := os.ReadFile(configPath)
+// validatedManagerFromLayers 校验配置层并创建文件类型管理器
+func validatedManagerFromLayers(layers []ConfigLayer) (*FileTypeManager, []ValidationIssue, error) {
+	if len(layers) == 0 {
+		return nil, nil, fmt.Errorf("未找到任何配置文件")
+	}
+
+	// 先逐个文件校验，文件级问题可以定位到行号
+	if issues := ValidateConfigLayers(layers); len(issues) > 0 {
+		return nil, issues, nil
+	}
+
+	ftm, err := newFileTypeManagerFromTree(MergeConfigLayers(layers))
 	if err != nil {
-		return nil, fmt.Errorf("读取 commit 模板配置文件失败: %v", err)
+		return nil, nil, err
 	}
-	
-	var config CommitTemplateConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("解析 commit 模板配置文件失败: %v", err)
+	return ftm, validateMergedTree(ftm), nil
+}
+
+// newFileTypeManagerFromTree 从合并后的配置树创建文件类型管理器
+func newFileTypeManagerFromTree(tree map[string]interface{}) (*FileTypeManager, error) {
+	fileTypes := &FileTypeConfig{}
+	if err := decodeTree(tree, fileTypes); err != nil {
+		return nil, fmt.Errorf("解析文件类型配置失败: %v", err)
 	}
-	
-	return &config, nil
-}
-
-// GetFileType 获取文件类型描述
-func (ftm *FileTypeManager) GetFileType(filename string) string {
-	// 遍历所有文件类型配置
-	for _, category := range ftm.fileTypes.FileTypes {
-		for _, typeItem := range category {
-			for _, ext := range typeItem.Extensions {
-				if strings.HasSuffix(filename, ext) {
-					return typeItem.Description
-				}
-			}
-		}
+
+	categories := &CategoryConfig{}
+	if err := decodeTree(tree["categories"], categories); err != nil {
+		return nil, fmt.Errorf("解析分类配置失败: %v", err)
 	}
-	
-	return "其他文件"
-}
-
-// GetFileCategory 获取文件分类
-func (ftm *FileTypeManager) GetFileCategory(filepath string) string {
-	// 检查目录模式
-	for _, pattern := range ftm.categories.DirectoryPatterns {
-		for _, pat := range pattern.Patterns {
-			if strings.Contains(filepath, pat) {
-				return pattern.Description
-			}
-		}
+
+	commitTemplates := &CommitTemplateConfig{}
+	if err := decodeTree(tree["commit_templates"], commitTemplates); err != nil {
+		return nil, fmt.Errorf("解析 commit 模板配置失败: %v", err)
 	}
-	
-	// 返回默认分类
-	return ftm.categories.Default
+
+	return &FileTypeManager{
+		fileTypes:     fileTypes,
+		categories:    categories,
+		commitTemplates: commitTemplates,
+		categoryRules:   compileCategoryRules(categories.DirectoryPatterns),
+		typeIndex:       newFileTypeIndex(fileTypes),
+	}, nil
 }
 
 // GetCommitType 获取 commit 类型
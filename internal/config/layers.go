@@ -0,0 +1,299 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"github.com/your-repo/cyben-zen-tools/configs"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFileName 仓库 / 目录级覆盖配置文件名
+const RepoConfigFileName = ".cyber-zen.yaml"
+
+// EmbeddedConfigPrefix 内置配置文件的路径前缀
+const EmbeddedConfigPrefix = "embedded:configs/"
+
+// 配置层来源
+const (
+	ScopeBuiltin = "builtin" // 编译进程序的默认配置
+	ScopeSystem  = "system"  // 可执行文件同目录的 configs
+	ScopeUser    = "user"    // 用户配置 (~/.cyber-zen/configs)
+	ScopeRepo    = "repo"    // 仓库及子目录中的 .cyber-zen.yaml
+)
+
+// layerFiles 配置目录中参与合并的文件
+var layerFiles = []string{"file-types.yaml", "categories.yaml", "commit-templates.yaml"}
+
+// ConfigLayer 一层配置
+type ConfigLayer struct {
+	Scope string                 // 来源: builtin, system, user, profile, repo
+	Path  string                 // 配置文件路径，内置配置以 embedded:configs/ 开头
+	Data  []byte                 // 原始文件内容
+	Tree  map[string]interface{} // 解析后的配置树
+}
+
+// LoadConfigLayers 按优先级从低到高加载所有配置层
+//
+// 顺序: 内置默认 < 可执行文件同目录 < 用户目录 < 配置档案 < 仓库根目录 .cyber-zen.yaml
+// < 子目录 .cyber-zen.yaml（越靠近 dir 优先级越高）
+func LoadConfigLayers(dir string) ([]ConfigLayer, error) {
+	layers, err := loadEmbeddedLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	if systemDir := getSystemConfigDir(); systemDir != "" {
+		dirLayers, err := loadConfigDirLayers(systemDir, ScopeSystem)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, dirLayers...)
+	}
+
+	if userDir := GetUserConfigDir(); userDir != "" {
+		dirLayers, err := loadConfigDirLayers(userDir, ScopeUser)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, dirLayers...)
+	}
+
+	if layer, ok := profileLayer(activeProfile); ok {
+		layers = append(layers, layer)
+	}
+
+	for _, path := range FindRepoConfigFiles(dir) {
+		layer, err := loadConfigLayerFile(path, ScopeRepo)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+// MergeConfigLayers 将所有配置层深度合并为一棵配置树
+func MergeConfigLayers(layers []ConfigLayer) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, layer := range layers {
+		deepMerge(merged, layer.Tree)
+	}
+	return merged
+}
+
+// FindRepoConfigFiles 查找从仓库根目录到 dir 路径上的所有 .cyber-zen.yaml
+//
+// 返回顺序为根目录在前、dir 在后；dir 不在 Git 仓库中时只检查 dir 本身。
+func FindRepoConfigFiles(dir string) []string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	// 自下而上收集目录，直到仓库根目录
+	var dirs []string
+	current := absDir
+	for {
+		dirs = append(dirs, current)
+		if isRepoRoot(current) {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// 不在仓库中，只使用 dir 本身
+			dirs = []string{absDir}
+			break
+		}
+		current = parent
+	}
+
+	var files []string
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(dirs[i], RepoConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// FindRepoRoot 查找 dir 所在仓库的根目录，不在仓库中时返回空字符串
+func FindRepoRoot(dir string) string {
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if isRepoRoot(current) {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		current = parent
+	}
+}
+
+// isRepoRoot 判断目录是否为 Git 仓库根目录（.git 可能是目录或 worktree 文件）
+func isRepoRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// loadConfigDirLayers 加载配置目录中的各个配置文件
+func loadConfigDirLayers(dir, scope string) ([]ConfigLayer, error) {
+	var layers []ConfigLayer
+	for _, name := range layerFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		layer, err := loadConfigLayerFile(path, scope)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// loadEmbeddedLayers 加载编译进程序的默认配置
+func loadEmbeddedLayers() ([]ConfigLayer, error) {
+	var layers []ConfigLayer
+	for _, name := range layerFiles {
+		data, err := configs.FS.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("读取内置配置失败 %s: %v", name, err)
+		}
+		layer, err := parseConfigLayer(ScopeBuiltin, EmbeddedConfigPrefix+name, data)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// loadConfigLayerFile 从磁盘读取一个配置层
+func loadConfigLayerFile(path, scope string) (ConfigLayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ConfigLayer{}, fmt.Errorf("读取配置文件失败 %s: %v", path, err)
+	}
+	return parseConfigLayer(scope, path, data)
+}
+
+// parseConfigLayer 解析配置层内容，旧版本格式在内存中升级到当前版本
+func parseConfigLayer(scope, path string, data []byte) (ConfigLayer, error) {
+	doc, err := migrateConfigData(path, data)
+	if err != nil {
+		return ConfigLayer{}, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+	}
+
+	// 旧版本文件已在内存中升级，配置树取自升级后的文档
+	tree := make(map[string]interface{})
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&tree); err != nil {
+			return ConfigLayer{}, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+		}
+	}
+	return ConfigLayer{Scope: scope, Path: path, Data: data, Tree: tree}, nil
+}
+
+// loadYAMLTree 读取 YAML 文件为通用配置树
+func loadYAMLTree(path string) (map[string]interface{}, error) {
+	layer, err := loadConfigLayerFile(path, "")
+	if err != nil {
+		return nil, err
+	}
+	return layer.Tree, nil
+}
+
+// deepMerge 将 src 深度合并到 dst：映射递归合并，列表和标量整体替换
+func deepMerge(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			deepMerge(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			// 复制一份，避免后续合并修改原始配置层
+			copied := make(map[string]interface{})
+			deepMerge(copied, srcMap)
+			dst[key] = copied
+			continue
+		}
+		dst[key] = srcValue
+	}
+}
+
+// decodeTree 将通用配置树解码为结构体
+func decodeTree(tree interface{}, out interface{}) error {
+	if tree == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// getSystemConfigDir 获取可执行文件同目录的 configs
+func getSystemConfigDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	configPath := filepath.Join(filepath.Dir(exe), "configs")
+	if _, err := os.Stat(configPath); err != nil {
+		return ""
+	}
+	return configPath
+}
+
+// GetUserConfigDir 获取用户配置目录（config_dir，默认 ~/.cyber-zen/configs）
+func GetUserConfigDir() string {
+	if dir := viper.GetString("config_dir"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".cyber-zen", "configs")
+}
+
+// WriteDefaultConfigs 将内置默认配置写入 dir，已存在的文件仅在 force 时覆盖
+//
+// 返回写入和跳过的文件路径。
+func WriteDefaultConfigs(dir string, force bool) (written, skipped []string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("创建配置目录失败: %v", err)
+	}
+
+	for _, name := range layerFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !force {
+			skipped = append(skipped, path)
+			continue
+		}
+
+		data, err := configs.FS.ReadFile(name)
+		if err != nil {
+			return written, skipped, fmt.Errorf("读取内置配置失败 %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, skipped, fmt.Errorf("写入配置文件失败 %s: %v", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, skipped, nil
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDeepMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"categories": map[string]interface{}{
+			"default": "其他文件",
+			"directory_patterns": map[string]interface{}{
+				"test": map[string]interface{}{"patterns": []interface{}{"test"}},
+			},
+		},
+		"protected_branches": []interface{}{"main"},
+	}
+	src := map[string]interface{}{
+		"categories": map[string]interface{}{
+			"directory_patterns": map[string]interface{}{
+				"proto": map[string]interface{}{"patterns": []interface{}{"proto"}},
+			},
+		},
+		"protected_branches": []interface{}{"release/*"},
+	}
+
+	deepMerge(dst, src)
+
+	categories := dst["categories"].(map[string]interface{})
+	if categories["default"] != "其他文件" {
+		t.Errorf("期望保留 default，实际为 %v", categories["default"])
+	}
+	patterns := categories["directory_patterns"].(map[string]interface{})
+	if _, ok := patterns["test"]; !ok {
+		t.Error("期望保留原有的 test 分类")
+	}
+	if _, ok := patterns["proto"]; !ok {
+		t.Error("期望合并新增的 proto 分类")
+	}
+
+	branches := dst["protected_branches"].([]interface{})
+	if len(branches) != 1 || branches[0] != "release/*" {
+		t.Errorf("期望列表被整体替换为 [release/*]，实际为 %v", branches)
+	}
+}
+
+func TestFindRepoConfigFiles(t *testing.T) {
+	repo := t.TempDir()
+	pkg := filepath.Join(repo, "packages", "web")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+
+	rootFile := filepath.Join(repo, RepoConfigFileName)
+	pkgFile := filepath.Join(pkg, RepoConfigFileName)
+	for _, path := range []string{rootFile, pkgFile} {
+		if err := os.WriteFile(path, []byte("compress:\n  rate: 0.5\n"), 0644); err != nil {
+			t.Fatalf("写入配置失败: %v", err)
+		}
+	}
+
+	files := FindRepoConfigFiles(pkg)
+	if len(files) != 2 || files[0] != rootFile || files[1] != pkgFile {
+		t.Errorf("期望 [%s %s]，实际为 %v", rootFile, pkgFile, files)
+	}
+
+	if root := FindRepoRoot(pkg); root != repo {
+		t.Errorf("期望仓库根目录为 %s，实际为 %s", repo, root)
+	}
+}
+
+func TestDirFileTypeManagers(t *testing.T) {
+	viper.Set("config_dir", t.TempDir())
+	t.Cleanup(viper.Reset)
+
+	repo := t.TempDir()
+	pkg := filepath.Join(repo, "packages", "foo")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+	override := "categories:\n  default: foo 包\n"
+	if err := os.WriteFile(filepath.Join(pkg, RepoConfigFileName), []byte(override), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	// 子目录的配置只作用于该目录下的文件（包括已删除的文件），与当前目录无关
+	managers := NewDirFileTypeManagers()
+	tests := []struct {
+		path     string
+		category string
+	}{
+		{filepath.Join(pkg, "main.go"), "foo 包"},
+		{filepath.Join(pkg, "deleted", "old.go"), "foo 包"},
+		{filepath.Join(repo, "main.go"), "功能模块"},
+		{filepath.Join(repo, "packages", "bar", "main.go"), "功能模块"},
+	}
+	for _, tt := range tests {
+		ftm, err := managers.ForFile(tt.path)
+		if err != nil {
+			t.Fatalf("创建文件类型管理器失败: %v", err)
+		}
+		if category := ftm.GetFileCategory("unmatched.txt"); category != tt.category {
+			t.Errorf("%s: 期望默认分类为 %s，实际为 %s", tt.path, tt.category, category)
+		}
+	}
+	if len(managers.managers) != 2 {
+		t.Errorf("生效配置相同的目录应共用管理器，实际创建了 %d 个", len(managers.managers))
+	}
+}
+
+func TestFileTypeManagerFromMergedTree(t *testing.T) {
+	dir := t.TempDir()
+	builtin := `categories:
+  directory_patterns:
+    test:
+      patterns: ["test"]
+      description: "测试文件"
+  default: "其他文件"
+`
+	override := `categories:
+  default: "杂项"
+commit_templates:
+  actions:
+    modified: "更新"
+`
+	builtinPath := filepath.Join(dir, "categories.yaml")
+	overridePath := filepath.Join(dir, RepoConfigFileName)
+	if err := os.WriteFile(builtinPath, []byte(builtin), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+	if err := os.WriteFile(overridePath, []byte(override), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	var layers []ConfigLayer
+	for _, item := range []struct{ scope, path string }{{ScopeBuiltin, builtinPath}, {ScopeRepo, overridePath}} {
+		tree, err := loadYAMLTree(item.path)
+		if err != nil {
+			t.Fatalf("加载配置失败: %v", err)
+		}
+		layers = append(layers, ConfigLayer{Scope: item.scope, Path: item.path, Tree: tree})
+	}
+
+	ftm, err := newFileTypeManagerFromTree(MergeConfigLayers(layers))
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+
+	if category := ftm.GetFileCategory("test/a.go"); category != "测试文件" {
+		t.Errorf("期望分类为 测试文件，实际为 %s", category)
+	}
+	if category := ftm.GetFileCategory("main.go"); category != "杂项" {
+		t.Errorf("期望默认分类被覆盖为 杂项，实际为 %s", category)
+	}
+	if action := ftm.GetActionDescription("modified"); action != "更新" {
+		t.Errorf("期望动作描述为 更新，实际为 %s", action)
+	}
+}
+
+func TestEmbeddedDefaults(t *testing.T) {
+	layers, err := loadEmbeddedLayers()
+	if err != nil {
+		t.Fatalf("加载内置配置失败: %v", err)
+	}
+
+	ftm, issues, err := validatedManagerFromLayers(layers)
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+	if len(issues) > 0 {
+		t.Fatalf("内置配置校验失败: %v", issues)
+	}
+	if fileType := ftm.GetFileType("main.go"); fileType == "其他文件" {
+		t.Error("期望内置配置能识别 .go 文件")
+	}
+}
+
+func TestWriteDefaultConfigs(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "categories.yaml")
+	if err := os.WriteFile(custom, []byte("categories:\n  default: 自定义\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	written, skipped, err := WriteDefaultConfigs(dir, false)
+	if err != nil {
+		t.Fatalf("写入默认配置失败: %v", err)
+	}
+	if len(written) != 2 || len(skipped) != 1 || skipped[0] != custom {
+		t.Errorf("期望写入 2 个、跳过 categories.yaml，实际写入 %v，跳过 %v", written, skipped)
+	}
+
+	if _, _, err := WriteDefaultConfigs(dir, true); err != nil {
+		t.Fatalf("覆盖默认配置失败: %v", err)
+	}
+	data, _ := os.ReadFile(custom)
+	if len(data) < 100 {
+		t.Error("期望 --force 覆盖已存在的配置文件")
+	}
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LinguistSource 导入的文件类型在 source 字段中的标记
+const LinguistSource = "linguist"
+
+// LinguistLanguage GitHub Linguist languages.yml 中的一种语言
+type LinguistLanguage struct {
+	Type         string   `yaml:"type"`
+	Group        string   `yaml:"group"`
+	Extensions   []string `yaml:"extensions"`
+	Filenames    []string `yaml:"filenames"`
+	Interpreters []string `yaml:"interpreters"`
+}
+
+// LinguistImportResult 导入结果
+type LinguistImportResult struct {
+	Path     string   // 写入的文件
+	Imported int      // 导入的语言数
+	Removed  int      // 移除的上次导入条目数
+	Skipped  []string // 与手写配置重名或规则已全部被占用的语言
+}
+
+// linguistTypeCategories Linguist 语言类型与顶层分类的对应关系
+var linguistTypeCategories = map[string]string{
+	"programming": "backend",
+	"markup":      "frontend",
+	"data":        "data",
+	"prose":       "docs",
+}
+
+// linguistLanguageCategories 需要单独归类的语言（按语言名或 group 匹配）
+var linguistLanguageCategories = map[string]string{
+	"JavaScript":   "frontend",
+	"TypeScript":   "frontend",
+	"TSX":          "frontend",
+	"CoffeeScript": "frontend",
+	"Elm":          "frontend",
+	"Vue":          "frontend",
+	"Svelte":       "frontend",
+	"CSS":          "frontend",
+	"SCSS":         "frontend",
+	"Sass":         "frontend",
+	"Less":         "frontend",
+	"Stylus":       "frontend",
+	"Shell":        "docs",
+	"PowerShell":   "docs",
+	"Batchfile":    "docs",
+	"fish":         "docs",
+	"Dockerfile":   "build",
+	"Makefile":     "build",
+	"CMake":        "build",
+	"Meson":        "build",
+	"Starlark":     "build",
+	"Nix":          "build",
+	"HCL":          "build",
+	"Gradle":       "build",
+}
+
+// linguistTypeDescriptions 各语言类型的描述格式
+var linguistTypeDescriptions = map[string]string{
+	"programming": "%s 代码",
+	"markup":      "%s 文件",
+	"data":        "%s 数据文件",
+	"prose":       "%s 文档",
+}
+
+// ImportLinguist 将 Linguist 的 languages.yml 转换为文件类型配置并写入 outputPath
+//
+// 手写条目（没有 source: linguist 的条目）始终保留：同名类型不会被覆盖，
+// 已被手写条目占用的扩展名、文件名和解释器也不会重复导入。
+// 上次导入的条目会被整体替换，文件中的注释会被保留。
+func ImportLinguist(dir, languagesPath, outputPath string) (LinguistImportResult, error) {
+	result := LinguistImportResult{Path: outputPath}
+
+	data, err := os.ReadFile(languagesPath)
+	if err != nil {
+		return result, fmt.Errorf("读取 Linguist 语言定义失败: %v", err)
+	}
+	languages := make(map[string]LinguistLanguage)
+	if err := yaml.Unmarshal(data, &languages); err != nil {
+		return result, fmt.Errorf("解析 Linguist 语言定义失败 %s: %v", languagesPath, err)
+	}
+
+	doc, err := loadYAMLDocument(outputPath)
+	if err != nil {
+		return result, err
+	}
+	result.Removed = removeLinguistEntries(doc)
+
+	claims, err := handWrittenClaims(dir, doc)
+	if err != nil {
+		return result, err
+	}
+
+	for _, name := range sortedKeys(languages) {
+		language := languages[name]
+		category := linguistCategory(name, language)
+		typeName := linguistTypeName(name)
+
+		item := FileTypeItem{
+			Extensions:  claims.filter("ext", language.Extensions),
+			Filenames:   claims.filter("file", language.Filenames),
+			Shebangs:    claims.filter("shebang", language.Interpreters),
+			Description: linguistDescription(name, language.Type),
+			Source:      LinguistSource,
+		}
+		if typeName == "" || claims.types[category+"."+typeName] ||
+			len(item.Extensions)+len(item.Filenames)+len(item.Shebangs) == 0 {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(item); err != nil {
+			return result, fmt.Errorf("编码语言 %s 失败: %v", name, err)
+		}
+		if err := setYAMLNode(doc, []string{"file_types", category, typeName}, node); err != nil {
+			return result, err
+		}
+		claims.types[category+"."+typeName] = true
+		result.Imported++
+	}
+
+	output, err := encodeYAMLDocument(doc)
+	if err != nil {
+		return result, err
+	}
+	if issues := validateConfigData(outputPath, output, configFileSchema("file-types.yaml")); len(issues) > 0 {
+		return result, &ValidationError{Issues: issues}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return result, fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	if err := os.WriteFile(outputPath, output, 0644); err != nil {
+		return result, fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return result, nil
+}
+
+// removeLinguistEntries 删除文档中上次导入的条目，返回删除数量
+func removeLinguistEntries(doc *yaml.Node) int {
+	fileTypes := mappingValue(doc.Content[0], "file_types")
+	if fileTypes == nil || fileTypes.Kind != yaml.MappingNode {
+		return 0
+	}
+
+	removed := 0
+	for i := 0; i+1 < len(fileTypes.Content); i += 2 {
+		category := fileTypes.Content[i+1]
+		if category.Kind != yaml.MappingNode {
+			continue
+		}
+		kept := category.Content[:0]
+		for j := 0; j+1 < len(category.Content); j += 2 {
+			source := mappingValue(category.Content[j+1], "source")
+			if source != nil && source.Value == LinguistSource {
+				removed++
+				continue
+			}
+			kept = append(kept, category.Content[j], category.Content[j+1])
+		}
+		category.Content = kept
+	}
+	return removed
+}
+
+// mappingValue 返回映射节点中 key 对应的值节点
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// linguistClaims 手写条目占用的类型名和识别规则
+type linguistClaims struct {
+	types map[string]bool // category.type
+	rules map[string]bool // kind:value，例如 ext:.go
+}
+
+// filter 过滤掉已被占用的规则，并记录新占用的规则
+func (c linguistClaims) filter(kind string, values []string) []string {
+	var kept []string
+	for _, value := range values {
+		key := kind + ":" + strings.ToLower(value)
+		if c.rules[key] {
+			continue
+		}
+		c.rules[key] = true
+		kept = append(kept, value)
+	}
+	return kept
+}
+
+// handWrittenClaims 收集生效配置和输出文件中所有手写条目占用的类型名和规则
+func handWrittenClaims(dir string, doc *yaml.Node) (linguistClaims, error) {
+	claims := linguistClaims{types: make(map[string]bool), rules: make(map[string]bool)}
+
+	layers, err := LoadConfigLayers(dir)
+	if err != nil {
+		return claims, err
+	}
+	merged := &FileTypeConfig{}
+	if err := decodeTree(MergeConfigLayers(layers), merged); err != nil {
+		return claims, fmt.Errorf("解析文件类型配置失败: %v", err)
+	}
+	output := &FileTypeConfig{}
+	if err := doc.Decode(output); err != nil {
+		return claims, fmt.Errorf("解析输出文件失败: %v", err)
+	}
+
+	for _, config := range []*FileTypeConfig{merged, output} {
+		for categoryName, category := range config.FileTypes {
+			for typeName, item := range category {
+				if item.Source == LinguistSource {
+					continue
+				}
+				claims.types[categoryName+"."+typeName] = true
+				claims.filter("ext", item.Extensions)
+				claims.filter("file", item.Filenames)
+				claims.filter("shebang", item.Shebangs)
+			}
+		}
+	}
+	return claims, nil
+}
+
+// linguistCategory 确定语言所属的顶层分类
+func linguistCategory(name string, language LinguistLanguage) string {
+	if category, ok := linguistLanguageCategories[name]; ok {
+		return category
+	}
+	if category, ok := linguistLanguageCategories[language.Group]; ok {
+		return category
+	}
+	if category, ok := linguistTypeCategories[language.Type]; ok {
+		return category
+	}
+	return "other"
+}
+
+// linguistTypeName 将语言名转换为类型键，例如 C++ -> cpp，Objective-C -> objective_c
+func linguistTypeName(name string) string {
+	name = strings.NewReplacer("+", "p", "#", "sharp").Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	parts := strings.FieldsFunc(b.String(), func(r rune) bool { return r == '_' })
+	return strings.Join(parts, "_")
+}
+
+// linguistDescription 生成语言描述
+func linguistDescription(name, languageType string) string {
+	format, ok := linguistTypeDescriptions[languageType]
+	if !ok {
+		format = "%s 文件"
+	}
+	return fmt.Sprintf(format, name)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testLanguages = `Go:
+  type: programming
+  extensions: [".go"]
+Zig:
+  type: programming
+  extensions: [".zig", ".zon"]
+  interpreters: [zig]
+Objective-C++:
+  type: programming
+  extensions: [".mm"]
+Dockerfile:
+  type: programming
+  extensions: [".dockerfile"]
+  filenames: [Dockerfile, Containerfile]
+Earthly:
+  type: programming
+  filenames: [Earthfile]
+YAML:
+  type: data
+  extensions: [".yml", ".yaml"]
+`
+
+func TestImportLinguist(t *testing.T) {
+	dir := t.TempDir()
+	languagesPath := filepath.Join(dir, "languages.yml")
+	if err := os.WriteFile(languagesPath, []byte(testLanguages), 0644); err != nil {
+		t.Fatalf("写入 languages.yml 失败: %v", err)
+	}
+	output := filepath.Join(dir, "file-types.yaml")
+	handWritten := `# 手写配置
+file_types:
+  backend:
+    zig:
+      extensions: [".zon"] # 只保留手写条目
+      description: "Zig 构建描述"
+`
+	if err := os.WriteFile(output, []byte(handWritten), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	result, err := ImportLinguist(dir, languagesPath, output)
+	if err != nil {
+		t.Fatalf("ImportLinguist 失败: %v", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("期望导入 2 个语言，实际为 %d（跳过 %v）", result.Imported, result.Skipped)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("读取配置失败: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# 手写配置", "# 只保留手写条目", "Zig 构建描述", "objective_cpp:", "earthly:", "source: linguist"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("期望配置包含 %q，实际为:\n%s", want, content)
+		}
+	}
+	if issues := ValidateConfigFile(output); len(issues) > 0 {
+		t.Errorf("导入结果应通过校验，实际为 %v", issues)
+	}
+
+	// 重新导入替换上次导入的条目，结果不变
+	again, err := ImportLinguist(dir, languagesPath, output)
+	if err != nil {
+		t.Fatalf("重新导入失败: %v", err)
+	}
+	if again.Removed != result.Imported || again.Imported != result.Imported {
+		t.Errorf("期望重新导入替换 %d 个条目，实际为 %+v", result.Imported, again)
+	}
+	reimported, _ := os.ReadFile(output)
+	if string(reimported) != content {
+		t.Errorf("重新导入结果应保持不变:\n%s\n---\n%s", content, reimported)
+	}
+}
+
+func TestFileTypeIndexPrefersHandWritten(t *testing.T) {
+	ftm, err := newFileTypeManagerFromTree(map[string]interface{}{
+		"file_types": map[string]interface{}{
+			"a_imported": map[string]interface{}{
+				"yaml": map[string]interface{}{
+					"extensions":  []interface{}{".yml"},
+					"description": "YAML 数据文件",
+					"source":      LinguistSource,
+				},
+			},
+			"data": map[string]interface{}{
+				"yaml": map[string]interface{}{
+					"extensions":  []interface{}{".yml"},
+					"description": "YAML 配置文件",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+	if got := ftm.GetFileType("ci.yml"); got != "YAML 配置文件" {
+		t.Errorf("期望手写条目优先，实际为 %q", got)
+	}
+}
+
+func TestLinguistTypeName(t *testing.T) {
+	tests := map[string]string{
+		"C++":           "cpp",
+		"C#":            "csharp",
+		"Objective-C":   "objective_c",
+		"Ren'Py":        "ren_py",
+		"Go Checksums":  "go_checksums",
+		"1C Enterprise": "1c_enterprise",
+	}
+	for name, want := range tests {
+		if got := linguistTypeName(name); got != want {
+			t.Errorf("linguistTypeName(%q) = %q，期望 %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion 当前配置文件格式版本，没有 version 字段的文件视为版本 1
+const CurrentConfigVersion = 2
+
+// configVersionKey 配置文件中的版本字段
+const configVersionKey = "version"
+
+// configMigration 将配置文件从 From 版本升级到 From+1 版本
+type configMigration struct {
+	From        int
+	Description string
+	// Migrate 就地修改文档的顶层映射，返回所做修改的说明；name 为配置文件名
+	Migrate func(name string, root *yaml.Node) []string
+}
+
+// configMigrations 迁移注册表，按版本顺序依次执行
+//
+// 修改配置格式时在此追加迁移并增加 CurrentConfigVersion。迁移直接修改 YAML 节点，
+// 注释和原始行号会被保留，校验问题仍能定位到原文件。
+var configMigrations = []configMigration{
+	{From: 1, Description: "配置段统一放入 file_types / categories / commit_templates 下，config.yaml 的键统一为小写", Migrate: migrateV1ToV2},
+}
+
+// MigrationResult 配置文件迁移结果
+type MigrationResult struct {
+	Path    string
+	From    int      // 文件原来的版本
+	To      int      // 迁移后的版本
+	Changes []string // 所做修改的说明
+	Backup  string   // 备份文件路径，未写入时为空
+}
+
+// Migrated 是否执行了迁移
+func (r MigrationResult) Migrated() bool {
+	return r.From < r.To
+}
+
+// WarningHandler 输出配置警告（例如旧版本配置在内存中升级），默认写入标准错误
+var WarningHandler = func(message string) {
+	fmt.Fprintln(os.Stderr, "警告: "+message)
+}
+
+// warnedFiles 已输出过迁移警告的文件，同一文件只警告一次
+var warnedFiles = make(map[string]bool)
+
+// migrateConfigDocument 将配置文档升级到当前版本
+//
+// 无法识别的文件和空文档不做处理；版本高于程序支持的版本时返回错误。
+func migrateConfigDocument(path string, doc *yaml.Node) (MigrationResult, error) {
+	result := MigrationResult{Path: path, From: CurrentConfigVersion, To: CurrentConfigVersion}
+	name := filepath.Base(path)
+	if _, ok := configSchemaFiles[name]; !ok || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return result, nil
+	}
+	root := doc.Content[0]
+
+	version := 1
+	if node := mappingValue(root, configVersionKey); node != nil {
+		parsed, err := strconv.Atoi(node.Value)
+		if err != nil || parsed < 1 {
+			return result, fmt.Errorf("%s:%d: version 必须是正整数", path, node.Line)
+		}
+		version = parsed
+	}
+	if version > CurrentConfigVersion {
+		return result, fmt.Errorf("%s: 配置版本 %d 高于程序支持的版本 %d，请升级 cyber-zen", path, version, CurrentConfigVersion)
+	}
+
+	result.From = version
+	for _, migration := range configMigrations {
+		if migration.From == version {
+			result.Changes = append(result.Changes, migration.Migrate(name, root)...)
+			version++
+		}
+	}
+	if result.Migrated() {
+		setConfigVersion(root, CurrentConfigVersion)
+	}
+	return result, nil
+}
+
+// migrateConfigData 解析并在内存中升级配置内容，旧版本文件输出一次警告
+//
+// 返回的错误为 yaml 解析错误或版本错误。
+func migrateConfigData(path string, data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	result, err := migrateConfigDocument(path, &doc)
+	if err != nil {
+		return nil, err
+	}
+	warnMigration(result)
+	return &doc, nil
+}
+
+// warnMigration 旧版本文件在内存中升级且内容有变化时输出警告，同一文件只警告一次
+//
+// 没有 version 字段但已符合当前格式的文件不会警告。
+func warnMigration(result MigrationResult) {
+	if len(result.Changes) == 0 || warnedFiles[result.Path] {
+		return
+	}
+	warnedFiles[result.Path] = true
+	WarningHandler(fmt.Sprintf("%s 使用旧版配置格式（version %d），已在内存中升级到 version %d（%s），运行 cyber-zen config migrate 更新文件",
+		result.Path, result.From, result.To, strings.Join(result.Changes, "；")))
+}
+
+// setConfigVersion 设置顶层 version 字段，不存在时插入到最前面
+func setConfigVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if node := mappingValue(root, configVersionKey); node != nil {
+		node.Value, node.Tag = value, "!!int"
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: configVersionKey}
+	// 文件开头的注释保留在最前面
+	if len(root.Content) > 0 {
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, {Kind: yaml.ScalarNode, Tag: "!!int", Value: value}}, root.Content...)
+}
+
+// MigrateConfigFile 将磁盘上的配置文件升级到当前版本
+//
+// 写入前校验升级后的内容，并将原文件备份为 <文件>.v<版本>.bak；文件中的注释会被保留。
+// dryRun 为 true 时只返回将要进行的修改。
+func MigrateConfigFile(path string, dryRun bool) (MigrationResult, error) {
+	result := MigrationResult{Path: path}
+	if _, ok := configSchemaFiles[filepath.Base(path)]; !ok {
+		return result, fmt.Errorf("无法识别的配置文件: %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return result, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+	}
+	if result, err = migrateConfigDocument(path, &doc); err != nil || !result.Migrated() || dryRun {
+		return result, err
+	}
+
+	output, err := encodeYAMLDocument(&doc)
+	if err != nil {
+		return result, err
+	}
+	if issues := validateConfigData(path, output, configFileSchema(path)); len(issues) > 0 {
+		return result, &ValidationError{Issues: issues}
+	}
+
+	result.Backup = fmt.Sprintf("%s.v%d.bak", path, result.From)
+	if err := os.WriteFile(result.Backup, data, 0644); err != nil {
+		return result, fmt.Errorf("备份配置文件失败: %v", err)
+	}
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return result, fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return result, nil
+}
+
+// MigrationTargets 返回 dir 下所有可迁移的配置文件：config.yaml、系统和用户配置目录、仓库 .cyber-zen.yaml
+func MigrationTargets(dir string) []string {
+	var targets []string
+	if _, err := os.Stat(settingsFile); err == nil {
+		targets = append(targets, settingsFile)
+	}
+	for _, configDir := range []string{getSystemConfigDir(), GetUserConfigDir()} {
+		if configDir == "" {
+			continue
+		}
+		for _, name := range layerFiles {
+			path := filepath.Join(configDir, name)
+			if _, err := os.Stat(path); err == nil {
+				targets = append(targets, path)
+			}
+		}
+	}
+	return append(targets, FindRepoConfigFiles(dir)...)
+}
+
+// migrateV1ToV2 版本 1 -> 2
+//
+// 版本 1 允许省略配置段的外层键（例如 categories.yaml 顶层直接写 directory_patterns），
+// config.yaml 由 viper 读取，键不区分大小写。
+func migrateV1ToV2(name string, root *yaml.Node) []string {
+	var changes []string
+	categoryKeys := yamlFieldNames(reflect.TypeOf(CategoryConfig{}))
+	templateKeys := yamlFieldNames(reflect.TypeOf(CommitTemplateConfig{}))
+
+	switch name {
+	case "file-types.yaml":
+		if mappingValue(root, "file_types") == nil {
+			var keys []string
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if key := root.Content[i].Value; key != configVersionKey {
+					keys = append(keys, key)
+				}
+			}
+			changes = append(changes, wrapMappingKeys(root, "file_types", keys)...)
+		}
+	case "categories.yaml":
+		changes = append(changes, wrapMappingKeys(root, "categories", categoryKeys)...)
+	case "commit-templates.yaml":
+		changes = append(changes, wrapMappingKeys(root, "commit_templates", templateKeys)...)
+	case RepoConfigFileName:
+		changes = append(changes, wrapMappingKeys(root, "categories", categoryKeys)...)
+		changes = append(changes, wrapMappingKeys(root, "commit_templates", templateKeys)...)
+	case "config.yaml":
+		changes = append(changes, lowercaseSettingKeys(nil, root, buildConfigSchema(name))...)
+	}
+	return changes
+}
+
+// yamlFieldNames 返回结构体各字段的 yaml 键名
+func yamlFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := schemaFieldName(t.Field(i), "yaml"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// wrapMappingKeys 将顶层映射中的 keys 移入 wrapper 映射，wrapper 放在第一个被移动的键的位置
+func wrapMappingKeys(root *yaml.Node, wrapper string, keys []string) []string {
+	moving := make(map[string]bool)
+	for _, key := range keys {
+		moving[key] = true
+	}
+
+	wrapped := mappingValue(root, wrapper)
+	var moved []string
+	var kept []*yaml.Node
+	insertAt := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if !moving[key.Value] || key.Value == wrapper {
+			kept = append(kept, key, value)
+			continue
+		}
+		if wrapped == nil {
+			wrapped = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			insertAt = len(kept)
+		}
+		// 包装后的映射已有同名键时保留包装内的值
+		if mappingValue(wrapped, key.Value) == nil {
+			wrapped.Content = append(wrapped.Content, key, value)
+		}
+		moved = append(moved, key.Value)
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	if insertAt >= 0 {
+		wrapperKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: wrapper}
+		// 注释跟随第一个被移动的键
+		wrapperKey.HeadComment, wrapped.Content[0].HeadComment = wrapped.Content[0].HeadComment, ""
+		kept = append(kept[:insertAt], append([]*yaml.Node{wrapperKey, wrapped}, kept[insertAt:]...)...)
+	}
+	root.Content = kept
+	return []string{fmt.Sprintf("%s 移入 %s", strings.Join(moved, "、"), wrapper)}
+}
+
+// lowercaseSettingKeys 将大小写不同的设置键改为 Schema 中的小写形式（版本 1 由 viper 读取，不区分大小写）
+func lowercaseSettingKeys(keyPath []string, node *yaml.Node, schema *JSONSchema) []string {
+	if node.Kind != yaml.MappingNode || schema == nil {
+		return nil
+	}
+
+	var changes []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		property, ok := schema.Properties[key.Value]
+		if !ok {
+			if lower := strings.ToLower(key.Value); lower != key.Value {
+				if property, ok = schema.Properties[lower]; ok {
+					changes = append(changes, fmt.Sprintf("%s 改为 %s", strings.Join(append(keyPath, key.Value), "."), lower))
+					key.Value = lower
+				}
+			}
+		}
+		if !ok {
+			// 映射的键（例如团队成员别名、档案名）保持原样
+			property, _ = schema.AdditionalProperties.(*JSONSchema)
+		}
+		childPath := append(append([]string{}, keyPath...), key.Value)
+		changes = append(changes, lowercaseSettingKeys(childPath, node.Content[i+1], property)...)
+	}
+	return changes
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureWarnings 在测试期间记录配置警告
+func captureWarnings(t *testing.T) *[]string {
+	t.Helper()
+	var warnings []string
+	original := WarningHandler
+	WarningHandler = func(message string) { warnings = append(warnings, message) }
+	t.Cleanup(func() { WarningHandler = original })
+	return &warnings
+}
+
+func TestMigrateWrapsSectionsInMemory(t *testing.T) {
+	warnings := captureWarnings(t)
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	content := `# 旧版分类
+directory_patterns:
+  test:
+    patterns: ["spec"]
+    description: "测试"
+default: "源码"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	layer, err := loadConfigLayerFile(path, ScopeUser)
+	if err != nil {
+		t.Fatalf("加载配置层失败: %v", err)
+	}
+	categories, ok := layer.Tree["categories"].(map[string]interface{})
+	if !ok || categories["default"] != "源码" || layer.Tree["version"] != CurrentConfigVersion {
+		t.Errorf("旧版配置应在内存中升级，实际为 %v", layer.Tree)
+	}
+	if len(*warnings) != 1 || !strings.Contains((*warnings)[0], "config migrate") {
+		t.Errorf("期望一条迁移警告，实际为 %v", *warnings)
+	}
+
+	// 校验使用升级后的内容，问题仍定位到原文件的行号
+	if err := os.WriteFile(path, []byte(content+"priority: x\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+	issues := ValidateConfigFile(path)
+	if len(issues) != 1 || issues[0].Line != 7 {
+		t.Errorf("期望第 7 行的未知字段，实际为 %v", issues)
+	}
+}
+
+func TestMigrateUnversionedCurrentFileIsSilent(t *testing.T) {
+	warnings := captureWarnings(t)
+	path := filepath.Join(t.TempDir(), RepoConfigFileName)
+	if err := os.WriteFile(path, []byte("protected_branches: [main]\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	if _, err := loadConfigLayerFile(path, ScopeRepo); err != nil {
+		t.Fatalf("加载配置层失败: %v", err)
+	}
+	if len(*warnings) != 0 {
+		t.Errorf("内容无需修改时不应警告，实际为 %v", *warnings)
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file-types.yaml")
+	if err := os.WriteFile(path, []byte("version: 99\nfile_types: {}\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	if _, err := loadConfigLayerFile(path, ScopeUser); err == nil || !strings.Contains(err.Error(), "升级 cyber-zen") {
+		t.Errorf("版本高于程序支持的版本时应报错，实际为 %v", err)
+	}
+	if issues := ValidateConfigFile(path); len(issues) != 1 {
+		t.Errorf("期望一个版本问题，实际为 %v", issues)
+	}
+}
+
+func TestMigrateConfigFileKeepsCommentsAndBackup(t *testing.T) {
+	captureWarnings(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `# 我的设置
+Language: en  # 英文
+Compress:
+  Rate: 0.6
+team:
+  Alice:
+    Name: Alice
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	preview, err := MigrateConfigFile(path, true)
+	if err != nil || !preview.Migrated() || preview.Backup != "" {
+		t.Fatalf("预览迁移失败: %+v, %v", preview, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Error("--dry-run 不应修改文件")
+	}
+
+	result, err := MigrateConfigFile(path, false)
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if len(result.Changes) != 4 {
+		t.Errorf("期望 4 处修改，实际为 %v", result.Changes)
+	}
+	if backup, _ := os.ReadFile(result.Backup); string(backup) != content {
+		t.Errorf("备份内容应为原文件，实际为 %q", backup)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# 我的设置", "version: 2", "language: en # 英文", "  rate: 0.6", "  Alice:\n    name: Alice"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("迁移后的文件缺少 %q:\n%s", want, data)
+		}
+	}
+
+	again, err := MigrateConfigFile(path, false)
+	if err != nil || again.Migrated() {
+		t.Errorf("已是最新版本的文件不应再次迁移: %+v, %v", again, err)
+	}
+}
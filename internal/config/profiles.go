@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/viper"
+)
+
+// ScopeProfile 用户 config.yaml 中的命名配置档案
+const ScopeProfile = "profile"
+
+// profileMatchKey 配置档案中用于按远程仓库地址自动选择的字段
+const profileMatchKey = "match"
+
+// profileReservedKeys 不能在配置档案中覆盖的设置
+var profileReservedKeys = map[string]bool{
+	"version":      true,
+	"install_dir":  true,
+	"config_dir":   true,
+	"platform":     true,
+	"architecture": true,
+	"profile":      true,
+	"profiles":     true,
+}
+
+// ActiveProfile 当前生效的配置档案
+type ActiveProfile struct {
+	Name   string                 // 档案名，空表示未使用档案
+	Source string                 // 选择方式，例如 --profile、CYBER_ZEN_PROFILE、remote origin
+	Tree   map[string]interface{} // 档案内容（不含 match）
+}
+
+// activeProfile 在 Init 中确定
+var activeProfile ActiveProfile
+
+// GetActiveProfile 获取当前生效的配置档案
+func GetActiveProfile() ActiveProfile {
+	return activeProfile
+}
+
+// loadProfiles 从用户 config.yaml 读取 profiles 段，保留键的大小写
+func loadProfiles() (map[string]map[string]interface{}, error) {
+	profiles := make(map[string]map[string]interface{})
+	if _, err := os.Stat(settingsFile); err != nil {
+		return profiles, nil
+	}
+
+	tree, err := loadYAMLTree(settingsFile)
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := tree["profiles"].(map[string]interface{})
+	if !ok {
+		return profiles, nil
+	}
+	for name, value := range raw {
+		// 不是映射的档案由 schema 校验报告，这里忽略
+		if profile, ok := value.(map[string]interface{}); ok {
+			profiles[name] = profile
+		}
+	}
+	return profiles, nil
+}
+
+// resolveProfile 确定生效的配置档案
+//
+// 顺序: --profile > CYBER_ZEN_PROFILE > config.yaml 中的 profile > 按远程仓库地址匹配 match
+// （origin 优先，模式越长越优先）
+func resolveProfile(dir string, profiles map[string]map[string]interface{}) (ActiveProfile, error) {
+	if name := viper.GetString("profile"); name != "" {
+		source := settingsFile
+		if flag, ok := boundFlags["profile"]; ok && flag.Changed {
+			source = "--" + flag.Name
+		} else if _, ok := os.LookupEnv(EnvName("profile")); ok {
+			source = EnvName("profile")
+		}
+
+		profile, ok := profiles[name]
+		if !ok {
+			return ActiveProfile{}, fmt.Errorf("配置档案不存在: %s（来自 %s）", name, source)
+		}
+		return ActiveProfile{Name: name, Source: source, Tree: withoutMatch(profile)}, nil
+	}
+
+	// 同一个远程地址命中多个档案时，模式最长（最具体）的档案优先
+	for _, remote := range listRemoteURLs(dir) {
+		normalized := NormalizeRemoteURL(remote.URL)
+		best, bestLength := "", -1
+		for _, name := range sortedKeys(profiles) {
+			for _, pattern := range profileMatchPatterns(profiles[name]) {
+				if ok, _ := doublestar.Match(strings.ToLower(pattern), normalized); ok && len(pattern) > bestLength {
+					best, bestLength = name, len(pattern)
+				}
+			}
+		}
+		if best != "" {
+			return ActiveProfile{
+				Name:   best,
+				Source: fmt.Sprintf("remote %s (%s)", remote.Name, remote.URL),
+				Tree:   withoutMatch(profiles[best]),
+			}, nil
+		}
+	}
+	return ActiveProfile{}, nil
+}
+
+// applyProfileSettings 将配置档案中的应用设置合并到 viper
+func applyProfileSettings(profile ActiveProfile) error {
+	settings := profileSettings(profile.Tree)
+	if len(settings) == 0 {
+		return nil
+	}
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("合并配置档案 %s 失败: %v", profile.Name, err)
+	}
+	return nil
+}
+
+// profileSettings 返回档案中的应用设置（不含文件类型管理器配置段）
+func profileSettings(tree map[string]interface{}) map[string]interface{} {
+	settings := make(map[string]interface{})
+	for key, value := range tree {
+		if _, ok := ftmSectionFiles[key]; ok {
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// dropInvalidProfileKeys 从配置档案中移除不能设置或未知的键，返回被移除键的说明
+//
+// 这些问题同时由 config.yaml 的 schema 校验报告，这里只保证它们不会生效，而不是让所有命令失败。
+func dropInvalidProfileKeys(profiles map[string]map[string]interface{}) []string {
+	var dropped []string
+	for _, name := range sortedKeys(profiles) {
+		for _, key := range sortedKeys(profiles[name]) {
+			if key == profileMatchKey {
+				continue
+			}
+			if _, ok := ftmSectionFiles[key]; ok {
+				continue
+			}
+			switch {
+			case profileReservedKeys[key]:
+				dropped = append(dropped, fmt.Sprintf("profiles.%s.%s 不能在配置档案中设置", name, key))
+			case !isSettingKey(key):
+				dropped = append(dropped, fmt.Sprintf("profiles.%s.%s 是未知的配置项", name, key))
+			default:
+				continue
+			}
+			delete(profiles[name], key)
+		}
+	}
+	return dropped
+}
+
+// profileLayer 返回配置档案中文件类型管理器配置段组成的配置层
+func profileLayer(profile ActiveProfile) (ConfigLayer, bool) {
+	if profile.Name == "" {
+		return ConfigLayer{}, false
+	}
+	tree := make(map[string]interface{})
+	for key, value := range profile.Tree {
+		if _, ok := ftmSectionFiles[key]; ok {
+			tree[key] = value
+		}
+	}
+	if len(tree) == 0 {
+		return ConfigLayer{}, false
+	}
+	return ConfigLayer{
+		Scope: ScopeProfile,
+		Path:  fmt.Sprintf("%s#profiles.%s", settingsFile, profile.Name),
+		Tree:  tree,
+	}, true
+}
+
+// withoutMatch 返回去掉 match 字段的档案副本
+func withoutMatch(profile map[string]interface{}) map[string]interface{} {
+	tree := make(map[string]interface{})
+	for key, value := range profile {
+		if key != profileMatchKey {
+			tree[key] = value
+		}
+	}
+	return tree
+}
+
+// profileMatchPatterns 读取档案的 match 字段，支持单个字符串或列表
+func profileMatchPatterns(profile map[string]interface{}) []string {
+	switch match := profile[profileMatchKey].(type) {
+	case string:
+		return []string{match}
+	case []interface{}:
+		var patterns []string
+		for _, item := range match {
+			if pattern, ok := item.(string); ok {
+				patterns = append(patterns, pattern)
+			}
+		}
+		return patterns
+	}
+	return nil
+}
+
+// gitRemote 远程仓库
+type gitRemote struct {
+	Name string
+	URL  string
+}
+
+// listRemoteURLs 列出 dir 所在仓库的远程地址，origin 排在最前
+func listRemoteURLs(dir string) []gitRemote {
+	cmd := exec.Command("git", "config", "--get-regexp", `^remote\..*\.url$`)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var remotes []gitRemote
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[0], "remote."), ".url")
+		remotes = append(remotes, gitRemote{Name: name, URL: fields[1]})
+	}
+
+	sort.SliceStable(remotes, func(i, j int) bool {
+		if (remotes[i].Name == "origin") != (remotes[j].Name == "origin") {
+			return remotes[i].Name == "origin"
+		}
+		return remotes[i].Name < remotes[j].Name
+	})
+	return remotes
+}
+
+// NormalizeRemoteURL 将远程地址规范化为 host/owner/repo 形式，便于匹配
+//
+// 例如 git@github.com:acme/app.git 和 https://user@github.com/acme/app 都规范化为
+// github.com/acme/app。
+func NormalizeRemoteURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 && !strings.Contains(url[:i], "/") {
+		// scp 形式: [user@]host:path
+		url = url[:i] + "/" + url[i+1:]
+	}
+
+	// 去掉用户信息和端口
+	host, rest, _ := strings.Cut(url, "/")
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+
+	rest = strings.TrimSuffix(strings.TrimSuffix(rest, "/"), ".git")
+	if rest == "" {
+		return host
+	}
+	return host + "/" + rest
+}
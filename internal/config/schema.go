@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonSchemaDialect 生成的 JSON Schema 版本
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema JSON Schema 文档（只包含本项目用到的关键字）
+//
+// Schema 由 Go 结构体通过反射生成，字段约束写在结构体的 schema 标签中，
+// 例如 `schema:"minimum=1,maximum=65535"`；列表字段的约束作用于列表项。
+// config validate 使用同一份 Schema 校验配置文件。
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"` // false 或 *JSONSchema
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	// ErrorMessage 不满足 pattern 时的提示（ajv-errors 约定的关键字）
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// configSchemaFile 可以生成 Schema 的配置文件
+type configSchemaFile struct {
+	Title   string
+	Type    reflect.Type
+	TagName string // 字段名取自哪个结构体标签
+}
+
+// configSchemaFiles 配置文件名与对应的结构体
+var configSchemaFiles = map[string]configSchemaFile{
+	"config.yaml":           {"Cyber Zen Tools 应用设置", reflect.TypeOf(Config{}), "mapstructure"},
+	"file-types.yaml":       {"Cyber Zen Tools 文件类型", reflect.TypeOf(FileTypeConfig{}), "yaml"},
+	"categories.yaml":       {"Cyber Zen Tools 文件分类", reflect.TypeOf(CategoryFile{}), "yaml"},
+	"commit-templates.yaml": {"Cyber Zen Tools Commit 模板", reflect.TypeOf(CommitTemplateFile{}), "yaml"},
+	RepoConfigFileName:      {"Cyber Zen Tools 仓库配置", reflect.TypeOf(RepoConfig{}), "yaml"},
+}
+
+// ConfigSchemaNames 返回所有可以生成 Schema 的配置文件名
+func ConfigSchemaNames() []string {
+	return sortedKeys(configSchemaFiles)
+}
+
+// ConfigSchema 生成配置文件的 JSON Schema
+//
+// name 可以是完整文件名（file-types.yaml、.cyber-zen.yaml）或去掉 .yaml 和前导 . 的简写（file-types、cyber-zen）。
+func ConfigSchema(name string) (*JSONSchema, error) {
+	for _, file := range ConfigSchemaNames() {
+		if name == file || name == strings.TrimPrefix(strings.TrimSuffix(file, ".yaml"), ".") {
+			return buildConfigSchema(file), nil
+		}
+	}
+	return nil, fmt.Errorf("未知的配置文件: %s（可选 %s）", name, strings.Join(ConfigSchemaNames(), "、"))
+}
+
+// SchemaFileName 返回配置文件对应的 Schema 文件名，例如 .cyber-zen.yaml -> cyber-zen.schema.json
+func SchemaFileName(name string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(name, ".yaml"), ".") + ".schema.json"
+}
+
+// configFileSchema 根据文件名返回对应的 Schema，未知文件返回 nil
+func configFileSchema(path string) *JSONSchema {
+	if _, ok := configSchemaFiles[filepath.Base(path)]; !ok {
+		return nil
+	}
+	return buildConfigSchema(filepath.Base(path))
+}
+
+// buildConfigSchema 根据结构体生成 Schema
+func buildConfigSchema(name string) *JSONSchema {
+	file := configSchemaFiles[name]
+	schema := schemaForType(file.Type, file.TagName)
+	schema.Schema = jsonSchemaDialect
+	schema.Title = file.Title
+	if version, ok := schema.Properties[configVersionKey]; ok {
+		maximum := float64(CurrentConfigVersion)
+		version.Maximum = &maximum
+	}
+
+	// profiles 在 Config 中是通用映射，档案的结构由应用设置和文件类型管理器配置段组成
+	if profiles, ok := schema.Properties["profiles"]; ok {
+		profiles.AdditionalProperties = profileSchema()
+	}
+	return schema
+}
+
+// profileSchema 单个配置档案的 Schema
+func profileSchema() *JSONSchema {
+	settings := schemaForType(reflect.TypeOf(Config{}), "mapstructure")
+	repo := schemaForType(reflect.TypeOf(RepoConfig{}), "yaml")
+
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema), AdditionalProperties: false}
+	for key, property := range settings.Properties {
+		if !profileReservedKeys[key] {
+			schema.Properties[key] = property
+		}
+	}
+	for key := range ftmSectionFiles {
+		schema.Properties[key] = repo.Properties[key]
+	}
+	schema.Properties[profileMatchKey] = &JSONSchema{AnyOf: []*JSONSchema{
+		{Type: "string", Pattern: `\S`, ErrorMessage: "值不能为空"},
+		{Type: "array", Items: &JSONSchema{Type: "string", Pattern: `\S`, ErrorMessage: "值不能为空"}},
+	}}
+	return schema
+}
+
+// schemaForType 通过反射生成类型的 Schema
+func schemaForType(t reflect.Type, tagName string) *JSONSchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaForType(t.Elem(), tagName)
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaForType(t.Elem(), tagName)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), tagName)}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema), AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := schemaFieldName(field, tagName)
+			if name == "" {
+				continue
+			}
+			property := schemaForType(field.Type, tagName)
+			applySchemaTag(property, field.Tag.Get("schema"))
+			schema.Properties[name] = property
+		}
+		return schema
+	}
+	// interface{} 等任意类型
+	return &JSONSchema{}
+}
+
+// schemaFieldName 返回字段在配置文件中的键名，优先使用 tagName 指定的标签
+func schemaFieldName(field reflect.StructField, tagName string) string {
+	if !field.IsExported() {
+		return ""
+	}
+	for _, tag := range []string{tagName, "yaml", "mapstructure"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(field.Name)
+}
+
+// schemaPatterns 可在 schema 标签中以 pattern=@名称 引用的正则，用于与代码共用或包含逗号的正则
+var schemaPatterns = map[string]string{
+	"byte_size": "^(" + ByteSizePattern + ")?$",
+}
+
+// applySchemaTag 解析 schema 标签（key=value，以逗号分隔）；列表字段的约束作用于列表项
+func applySchemaTag(schema *JSONSchema, tag string) {
+	if tag == "" {
+		return
+	}
+	if schema.Type == "array" {
+		schema = schema.Items
+	}
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "minimum":
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Minimum = &number
+			}
+		case "maximum":
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.Maximum = &number
+			}
+		case "exclusiveMaximum":
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				schema.ExclusiveMaximum = &number
+			}
+		case "enum":
+			schema.Enum = strings.Split(value, "|")
+		case "pattern":
+			if name, ok := strings.CutPrefix(value, "@"); ok {
+				value = schemaPatterns[name]
+			}
+			schema.Pattern = value
+		case "format":
+			schema.Format = value
+		case "message":
+			schema.ErrorMessage = value
+		}
+	}
+}
+
+// schemaTypeNames Schema 类型在提示中的名称
+var schemaTypeNames = map[string]string{
+	"object":  "映射",
+	"array":   "列表",
+	"string":  "字符串",
+	"integer": "整数",
+	"number":  "数字",
+	"boolean": "布尔值",
+}
+
+// describeSchemaTypes 描述 Schema 允许的类型，例如 "字符串 或 列表"
+func describeSchemaTypes(schema *JSONSchema) string {
+	if len(schema.AnyOf) == 0 {
+		return schemaTypeNames[schema.Type]
+	}
+	var names []string
+	for _, option := range schema.AnyOf {
+		names = append(names, describeSchemaTypes(option))
+	}
+	sort.Strings(names)
+	return strings.Join(names, " 或 ")
+}
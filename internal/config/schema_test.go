@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSchemaFromStructs(t *testing.T) {
+	schema, err := ConfigSchema("cyber-zen")
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+	for _, key := range []string{"file_types", "categories", "commit_templates", "protected_branches", "compress", "gcm", "server"} {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("仓库配置 Schema 缺少 %s", key)
+		}
+	}
+
+	port := schema.Properties["server"].Properties["port"]
+	if port.Type != "integer" || port.Minimum == nil || *port.Minimum != 1 || *port.Maximum != 65535 {
+		t.Errorf("server.port 的 Schema 不符合预期: %+v", port)
+	}
+	extension := schema.Properties["file_types"].AdditionalProperties.(*JSONSchema).
+		AdditionalProperties.(*JSONSchema).Properties["extensions"]
+	if extension.Type != "array" || extension.Items.Pattern != `^\.` {
+		t.Errorf("列表字段的约束应作用于列表项: %+v", extension)
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("编码 Schema 失败: %v", err)
+	}
+	if !strings.Contains(string(data), `"additionalProperties":false`) {
+		t.Errorf("结构体应禁止未知字段: %s", data)
+	}
+
+	if _, err := ConfigSchema("unknown.yaml"); err == nil {
+		t.Error("未知的配置文件应报错")
+	}
+}
+
+func TestConfigSchemaProfiles(t *testing.T) {
+	schema, err := ConfigSchema("config.yaml")
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+	if got := schema.Properties["language"].Enum; len(got) != 2 {
+		t.Errorf("language 应只允许 zh、en，实际为 %v", got)
+	}
+
+	profile := schema.Properties["profiles"].AdditionalProperties.(*JSONSchema)
+	for _, key := range []string{"match", "language", "commit_templates"} {
+		if _, ok := profile.Properties[key]; !ok {
+			t.Errorf("配置档案 Schema 缺少 %s", key)
+		}
+	}
+	if _, ok := profile.Properties["install_dir"]; ok {
+		t.Error("配置档案 Schema 不应包含 install_dir")
+	}
+}
+
+func TestValidateSettingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `language: fr
+team:
+  alice:
+    name: Alice
+    phone: 123
+server:
+  port: abc
+profiles:
+  oss:
+    match: "github.com/**"
+    install_dir: /tmp
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	issues := ValidateConfigFile(path)
+	expected := map[int]string{
+		1:  "可选 zh、en",
+		5:  "未知字段 phone",
+		7:  "应为整数",
+		11: "未知字段 install_dir",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("期望 %d 个问题，实际为 %v", len(expected), issues)
+	}
+	for _, issue := range issues {
+		want, ok := expected[issue.Line]
+		if !ok || !strings.Contains(issue.Message, want) {
+			t.Errorf("第 %d 行的问题不符合预期: %s", issue.Line, issue.Message)
+		}
+	}
+}
+
+func TestValidateDuplicateKeys(t *testing.T) {
+	issues := validateConfigData("categories.yaml", []byte("categories:\n  default: a\n  default: b\n"), configFileSchema("categories.yaml"))
+	if len(issues) != 1 || issues[0].Line != 3 || !strings.Contains(issues[0].Message, "重复") {
+		t.Errorf("期望第 3 行报告重复的键，实际为 %v", issues)
+	}
+}
+
+func TestValidateTargetSizeUnits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	tests := map[string]bool{
+		`""`:    true,
+		"2048":  true,
+		"300KB": true,
+		"1.5M":  true,
+		"1GB":   true,
+		"1 g":   true,
+		"1TB":   false,
+		"abc":   false,
+	}
+	for value, valid := range tests {
+		if err := os.WriteFile(path, []byte("compress:\n  target_size: "+value+"\n"), 0644); err != nil {
+			t.Fatalf("写入配置失败: %v", err)
+		}
+		if issues := ValidateConfigFile(path); (len(issues) == 0) != valid {
+			t.Errorf("target_size %s: 期望合法 %v，实际问题 %v", value, valid, issues)
+		}
+	}
+}
+
+func TestValidateMinSavingsBound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	// 与 --min-savings 一致：0 到 100 之间，不含 100
+	tests := map[string]bool{
+		"0":    true,
+		"99.5": true,
+		"100":  false,
+		"-1":   false,
+	}
+	for value, valid := range tests {
+		if err := os.WriteFile(path, []byte("compress:\n  min_savings: "+value+"\n"), 0644); err != nil {
+			t.Fatalf("写入配置失败: %v", err)
+		}
+		issues := ValidateConfigFile(path)
+		if (len(issues) == 0) != valid {
+			t.Errorf("min_savings %s: 期望合法 %v，实际问题 %v", value, valid, issues)
+		}
+		if !valid && len(issues) > 0 && !strings.Contains(issues[0].Message, "不含 100.0") {
+			t.Errorf("min_savings %s: 提示应说明不含 100，实际为 %s", value, issues[0].Message)
+		}
+	}
+
+	schema, err := ConfigSchema("config.yaml")
+	if err != nil {
+		t.Fatalf("生成 Schema 失败: %v", err)
+	}
+	minSavings := schema.Properties["compress"].Properties["min_savings"]
+	if minSavings.Maximum != nil || minSavings.ExclusiveMaximum == nil || *minSavings.ExclusiveMaximum != 100 {
+		t.Errorf("min_savings 的 Schema 应为 exclusiveMaximum=100: %+v", minSavings)
+	}
+}
@@ -0,0 +1,365 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// 非文件来源的配置
+const (
+	ScopeDefault = "default" // 程序内置的默认值
+	ScopeEnv     = "env"     // CYBER_ZEN_* 环境变量
+	ScopeFlag    = "flag"    // 全局命令行标志
+)
+
+// ftmSectionFiles 文件类型管理器的配置段与配置目录中文件的对应关系
+var ftmSectionFiles = map[string]string{
+	"file_types":       "file-types.yaml",
+	"categories":       "categories.yaml",
+	"commit_templates": "commit-templates.yaml",
+}
+
+// ConfigValue 一个生效的配置项及其来源
+type ConfigValue struct {
+	Key    string
+	Value  interface{}
+	Scope  string // default, builtin, system, user, profile, repo, env, flag
+	Source string // 来源文件、环境变量名或标志名，默认值为空
+}
+
+// EffectiveValues 返回 dir 下生效的所有配置项（按键排序）
+//
+// 包含 viper 管理的 config.yaml 设置以及文件类型管理器的 YAML 配置。
+func EffectiveValues(dir string) ([]ConfigValue, error) {
+	var values []ConfigValue
+
+	// 文件类型管理器配置：值取自合并结果，来源为最后定义该键的配置层
+	layers, err := LoadConfigLayers(dir)
+	if err != nil {
+		return nil, err
+	}
+	origins := make(map[string]ConfigLayer)
+	for _, layer := range layers {
+		flattenTree("", layer.Tree, func(key string, _ interface{}) {
+			origins[key] = layer
+		})
+	}
+	flattenTree("", MergeConfigLayers(layers), func(key string, value interface{}) {
+		if !isFileTypeKey(key) {
+			return
+		}
+		layer := origins[key]
+		values = append(values, ConfigValue{Key: key, Value: value, Scope: layer.Scope, Source: layer.Path})
+	})
+
+	// 应用设置：值取自 viper，来源依次为默认值、用户 config.yaml、配置档案、仓库 .cyber-zen.yaml、环境变量、标志
+	settingOrigins := settingValueOrigins(dir)
+	for _, key := range viper.AllKeys() {
+		if isFileTypeKey(key) {
+			continue
+		}
+		value := ConfigValue{Key: key, Value: viper.Get(key), Scope: ScopeDefault}
+		if origin, ok := settingOrigins[key]; ok {
+			value.Scope = origin.Scope
+			value.Source = origin.Source
+		}
+		values = append(values, value)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+	return values, nil
+}
+
+// settingValueOrigins 计算应用设置各键的来源文件
+func settingValueOrigins(dir string) map[string]ConfigValue {
+	origins := make(map[string]ConfigValue)
+
+	if tree, err := loadYAMLTree(GetSettingsFile()); err == nil {
+		flattenTree("", tree, func(key string, _ interface{}) {
+			key = strings.ToLower(key)
+			origins[key] = ConfigValue{Key: key, Scope: ScopeUser, Source: GetSettingsFile()}
+		})
+	}
+
+	if activeProfile.Name != "" {
+		source := fmt.Sprintf("%s#profiles.%s", GetSettingsFile(), activeProfile.Name)
+		flattenTree("", profileSettings(activeProfile.Tree), func(key string, _ interface{}) {
+			key = strings.ToLower(key)
+			origins[key] = ConfigValue{Key: key, Scope: ScopeProfile, Source: source}
+		})
+	}
+
+	for _, path := range FindRepoConfigFiles(dir) {
+		tree, err := loadYAMLTree(path)
+		if err != nil {
+			continue
+		}
+		for _, top := range repoSettingKeys {
+			if subtree, ok := tree[top]; ok {
+				flattenTree(top, subtree, func(key string, _ interface{}) {
+					origins[key] = ConfigValue{Key: key, Scope: ScopeRepo, Source: path}
+				})
+			}
+		}
+	}
+
+	for _, key := range viper.AllKeys() {
+		if flag, ok := boundFlags[key]; ok && flag.Changed {
+			origins[key] = ConfigValue{Key: key, Scope: ScopeFlag, Source: "--" + flag.Name}
+		} else if _, ok := os.LookupEnv(EnvName(key)); ok {
+			origins[key] = ConfigValue{Key: key, Scope: ScopeEnv, Source: EnvName(key)}
+		}
+	}
+	return origins
+}
+
+// GetValue 获取配置项，key 可以是叶子键或中间键（返回整个子树）
+func GetValue(dir, key string) (ConfigValue, error) {
+	values, err := EffectiveValues(dir)
+	if err != nil {
+		return ConfigValue{}, err
+	}
+
+	for _, value := range values {
+		if value.Key == key {
+			return value, nil
+		}
+	}
+
+	// 中间键：返回子树
+	if isFileTypeKey(key) {
+		layers, err := LoadConfigLayers(dir)
+		if err != nil {
+			return ConfigValue{}, err
+		}
+		if subtree, ok := lookupTree(MergeConfigLayers(layers), key); ok {
+			return ConfigValue{Key: key, Value: subtree}, nil
+		}
+	} else if viper.IsSet(key) {
+		return ConfigValue{Key: key, Value: viper.Get(key)}, nil
+	}
+
+	return ConfigValue{}, fmt.Errorf("配置项不存在: %s", key)
+}
+
+// SetValue 将配置项写入指定作用域（user 或 repo）的配置文件，返回写入的文件路径
+//
+// value 按 YAML 解析，因此 0.6、true、[main, dev] 会得到对应的类型；文件中的注释会被保留。
+func SetValue(dir, key, value, scope string) (string, error) {
+	path, err := ResolveConfigFile(dir, key, scope)
+	if err != nil {
+		return "", err
+	}
+
+	var valueDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &valueDoc); err != nil {
+		return "", fmt.Errorf("无法解析配置值 %q: %v", value, err)
+	}
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ""}
+	if len(valueDoc.Content) > 0 {
+		valueNode = valueDoc.Content[0]
+	}
+
+	doc, err := loadYAMLDocument(path)
+	if err != nil {
+		return "", err
+	}
+	if err := setYAMLNode(doc, strings.Split(key, "."), valueNode); err != nil {
+		return "", err
+	}
+
+	data, err := encodeYAMLDocument(doc)
+	if err != nil {
+		return "", err
+	}
+
+	// 写入前校验，避免写出无法加载的配置
+	if schema := configFileSchema(path); schema != nil {
+		if issues := validateConfigData(path, data, schema); len(issues) > 0 {
+			return "", &ValidationError{Issues: issues}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return path, nil
+}
+
+// ResolveConfigFile 返回在指定作用域中保存 key 的配置文件
+//
+// key 为空时返回该作用域的主配置文件：用户为 config.yaml，仓库为根目录的 .cyber-zen.yaml。
+func ResolveConfigFile(dir, key, scope string) (string, error) {
+	top := strings.SplitN(key, ".", 2)[0]
+
+	switch scope {
+	case ScopeUser:
+		if key == "" {
+			return GetSettingsFile(), nil
+		}
+		if file, ok := ftmSectionFiles[top]; ok {
+			return filepath.Join(GetUserConfigDir(), file), nil
+		}
+		if isSettingKey(top) {
+			return GetSettingsFile(), nil
+		}
+	case ScopeRepo:
+		root := FindRepoRoot(dir)
+		if root == "" {
+			return "", fmt.Errorf("当前目录不在 Git 仓库中，无法使用 repo 作用域")
+		}
+		path := filepath.Join(root, RepoConfigFileName)
+		if key == "" {
+			return path, nil
+		}
+		if _, ok := ftmSectionFiles[top]; ok {
+			return path, nil
+		}
+		for _, repoKey := range repoSettingKeys {
+			if top == repoKey {
+				return path, nil
+			}
+		}
+		if isSettingKey(top) {
+			return "", fmt.Errorf("%s 只能在 user 作用域中设置", key)
+		}
+	default:
+		return "", fmt.Errorf("未知的作用域: %s（可选 user、repo）", scope)
+	}
+
+	return "", fmt.Errorf("未知的配置项: %s", key)
+}
+
+// isFileTypeKey 键是否属于文件类型管理器的配置段
+func isFileTypeKey(key string) bool {
+	_, ok := ftmSectionFiles[strings.SplitN(key, ".", 2)[0]]
+	return ok
+}
+
+// isSettingKey 顶层键是否为 Config 结构中的应用设置
+func isSettingKey(top string) bool {
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		if configType.Field(i).Tag.Get("mapstructure") == top {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenTree 将配置树展开为叶子键；映射继续展开，列表和标量作为叶子
+func flattenTree(prefix string, value interface{}, fn func(key string, value interface{})) {
+	if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+		for key, child := range m {
+			childKey := key
+			if prefix != "" {
+				childKey = prefix + "." + key
+			}
+			flattenTree(childKey, child, fn)
+		}
+		return
+	}
+	if prefix != "" {
+		fn(prefix, value)
+	}
+}
+
+// lookupTree 按点分隔的键在配置树中查找子树
+func lookupTree(tree map[string]interface{}, key string) (interface{}, bool) {
+	var current interface{} = tree
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// loadYAMLDocument 读取 YAML 文档节点，文件不存在时返回空文档
+func loadYAMLDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取配置文件失败 %s: %v", path, err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败 %s: %v", path, err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	return doc, nil
+}
+
+// encodeYAMLDocument 将文档节点编码为 YAML（两空格缩进）
+func encodeYAMLDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("编码配置失败: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("编码配置失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// setYAMLNode 在文档中按键路径设置值，缺失的中间映射会自动创建
+func setYAMLNode(doc *yaml.Node, path []string, value *yaml.Node) error {
+	current := doc.Content[0]
+	for i, part := range path {
+		if current.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s 不是映射，无法设置子键", strings.Join(path[:i], "."))
+		}
+
+		var child *yaml.Node
+		for j := 0; j+1 < len(current.Content); j += 2 {
+			if current.Content[j].Value == part {
+				child = current.Content[j+1]
+				if i == len(path)-1 {
+					// 保留原有节点上的注释
+					value.HeadComment = child.HeadComment
+					value.LineComment = child.LineComment
+					value.FootComment = child.FootComment
+					current.Content[j+1] = value
+					return nil
+				}
+				break
+			}
+		}
+
+		if child == nil {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}
+			if i == len(path)-1 {
+				current.Content = append(current.Content, keyNode, value)
+				return nil
+			}
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			current.Content = append(current.Content, keyNode, child)
+		}
+		current = child
+	}
+	return nil
+}
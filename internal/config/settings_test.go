@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetValuePreservesComments(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+	path := filepath.Join(repo, RepoConfigFileName)
+	original := `# 仓库配置
+compress:
+  # 默认压缩比率
+  rate: 0.8 # 旧值
+protected_branches: [main]
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	written, err := SetValue(repo, "compress.rate", "0.6", ScopeRepo)
+	if err != nil {
+		t.Fatalf("SetValue 失败: %v", err)
+	}
+	if written != path {
+		t.Errorf("期望写入 %s，实际为 %s", path, written)
+	}
+	if _, err := SetValue(repo, "categories.default", "其他文件", ScopeRepo); err != nil {
+		t.Fatalf("SetValue 失败: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取配置失败: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# 仓库配置", "# 默认压缩比率", "rate: 0.6 # 旧值", "default: 其他文件"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("期望配置包含 %q，实际为:\n%s", want, content)
+		}
+	}
+}
+
+func TestSetValueRejectsInvalid(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+
+	if _, err := SetValue(repo, "compress.rate", "5", ScopeRepo); err == nil {
+		t.Error("期望非法的压缩比率被拒绝")
+	}
+	if _, err := os.Stat(filepath.Join(repo, RepoConfigFileName)); !os.IsNotExist(err) {
+		t.Error("校验失败时不应写入配置文件")
+	}
+	if _, err := SetValue(repo, "install_dir", "/tmp", ScopeRepo); err == nil {
+		t.Error("期望 install_dir 不能在 repo 作用域中设置")
+	}
+}
+
+func TestResolveConfigFile(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		scope string
+		want  string
+	}{
+		{"categories.default", ScopeUser, filepath.Join(GetUserConfigDir(), "categories.yaml")},
+		{"file_types.code.go", ScopeUser, filepath.Join(GetUserConfigDir(), "file-types.yaml")},
+		{"categories.default", ScopeRepo, filepath.Join(repo, RepoConfigFileName)},
+		{"compress.rate", ScopeRepo, filepath.Join(repo, RepoConfigFileName)},
+	}
+	for _, tt := range tests {
+		got, err := ResolveConfigFile(repo, tt.key, tt.scope)
+		if err != nil {
+			t.Errorf("ResolveConfigFile(%s, %s) 失败: %v", tt.key, tt.scope, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveConfigFile(%s, %s) = %s，期望 %s", tt.key, tt.scope, got, tt.want)
+		}
+	}
+
+	if _, err := ResolveConfigFile(repo, "unknown.key", ScopeUser); err == nil {
+		t.Error("期望未知配置项返回错误")
+	}
+	if _, err := ResolveConfigFile(repo, "compress.rate", "global"); err == nil {
+		t.Error("期望未知作用域返回错误")
+	}
+}
+
+func TestEffectiveValuesOrigins(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("创建 .git 失败: %v", err)
+	}
+	path := filepath.Join(repo, RepoConfigFileName)
+	if err := os.WriteFile(path, []byte("categories:\n  default: 其他文件\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	values, err := EffectiveValues(repo)
+	if err != nil {
+		t.Fatalf("EffectiveValues 失败: %v", err)
+	}
+
+	found := map[string]ConfigValue{}
+	for _, value := range values {
+		found[value.Key] = value
+	}
+
+	def := found["categories.default"]
+	if def.Value != "其他文件" || def.Scope != ScopeRepo || def.Source != path {
+		t.Errorf("categories.default 期望来自仓库配置，实际为 %+v", def)
+	}
+	test := found["categories.directory_patterns.test.description"]
+	if test.Scope == ScopeRepo || test.Source == "" {
+		t.Errorf("未覆盖的配置项应来自默认配置文件，实际为 %+v", test)
+	}
+}
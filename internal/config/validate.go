@@ -0,0 +1,351 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfig .cyber-zen.yaml 文件结构
+type RepoConfig struct {
+	Version           int                         `yaml:"version,omitempty" schema:"minimum=1"`
+	FileTypes         map[string]FileTypeCategory `yaml:"file_types"`
+	Categories        CategoryConfig              `yaml:"categories"`
+	CommitTemplates   CommitTemplateConfig        `yaml:"commit_templates"`
+	ProtectedBranches []string                    `yaml:"protected_branches" schema:"pattern=\\S,message=值不能为空"`
+	Compress          CompressConfig              `yaml:"compress"`
+	Gcm               GcmConfig                   `yaml:"gcm"`
+	Server            ServerConfig                `yaml:"server"`
+}
+
+// ValidationIssue 配置校验问题
+type ValidationIssue struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// String 格式化为 file:line: message
+func (i ValidationIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", i.File, i.Message)
+}
+
+// ValidationError 配置校验失败
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	lines := []string{fmt.Sprintf("配置校验失败（%d 个问题）:", len(e.Issues))}
+	for _, issue := range e.Issues {
+		lines = append(lines, "  "+issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlLineError 匹配 yaml 错误中的行号
+var yamlLineError = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// ValidateConfigFile 校验单个配置文件：语法、未知字段、类型和取值
+func ValidateConfigFile(path string) []ValidationIssue {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []ValidationIssue{{File: path, Message: fmt.Sprintf("读取失败: %v", err)}}
+	}
+
+	schema := configFileSchema(path)
+	if schema == nil {
+		return []ValidationIssue{{File: path, Message: "无法识别的配置文件（支持 " + strings.Join(ConfigSchemaNames(), "、") + "）"}}
+	}
+	return validateConfigData(path, data, schema)
+}
+
+// validateConfigData 按 JSON Schema 严格校验配置内容（旧版本格式先升级到当前版本）
+func validateConfigData(path string, data []byte, schema *JSONSchema) []ValidationIssue {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return yamlErrorIssues(path, err)
+	}
+
+	// 旧版本格式先在内存中升级，迁移保留原始行号
+	result, err := migrateConfigDocument(path, &root)
+	if err != nil {
+		return []ValidationIssue{{File: path, Message: err.Error()}}
+	}
+	warnMigration(result)
+
+	if len(root.Content) == 0 {
+		return nil
+	}
+	return validateSchemaNode(path, nil, root.Content[0], schema)
+}
+
+// yamlErrorIssues 将 yaml 错误拆分为带行号的问题
+func yamlErrorIssues(path string, err error) []ValidationIssue {
+	var messages []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	var issues []ValidationIssue
+	for _, message := range messages {
+		issue := ValidationIssue{File: path, Message: message}
+		if match := yamlLineError.FindStringSubmatch(message); match != nil {
+			issue.Line, _ = strconv.Atoi(match[1])
+			issue.Message = match[2]
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// validateSchemaNode 按 Schema 校验 YAML 节点，问题定位到节点所在行
+//
+// null 视为空值：类型不限，但仍需满足取值约束（例如 description 不能为空）。
+func validateSchemaNode(path string, keyPath []string, node *yaml.Node, schema *JSONSchema) []ValidationIssue {
+	issue := func(line int, format string, args ...interface{}) []ValidationIssue {
+		message := fmt.Sprintf(format, args...)
+		if len(keyPath) > 0 {
+			message = strings.Join(keyPath, ".") + ": " + message
+		}
+		return []ValidationIssue{{File: path, Line: line, Message: message}}
+	}
+
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		if message := checkScalarValue("", schema); message != "" {
+			return issue(node.Line, "%s", message)
+		}
+		return nil
+	}
+
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			if issues := validateSchemaNode(path, keyPath, node, option); len(issues) == 0 {
+				return nil
+			}
+		}
+		// 类型匹配的分支给出的问题更具体
+		for _, option := range schema.AnyOf {
+			if schemaNodeTypeMatches(node, option) {
+				return validateSchemaNode(path, keyPath, node, option)
+			}
+		}
+		return issue(node.Line, "应为%s", describeSchemaTypes(schema))
+	}
+
+	if !schemaNodeTypeMatches(node, schema) {
+		return issue(node.Line, "应为%s，实际为 %s", describeSchemaTypes(schema), describeYAMLNode(node))
+	}
+
+	var issues []ValidationIssue
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := make(map[string]int)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if line, ok := seen[key.Value]; ok {
+				issues = append(issues, issue(key.Line, "键 %s 重复（第 %d 行已定义）", key.Value, line)...)
+				continue
+			}
+			seen[key.Value] = key.Line
+
+			property, ok := schema.Properties[key.Value]
+			if !ok {
+				property, ok = schema.AdditionalProperties.(*JSONSchema)
+			}
+			if !ok {
+				issues = append(issues, issue(key.Line, "未知字段 %s", key.Value)...)
+				continue
+			}
+			childPath := append(append([]string{}, keyPath...), key.Value)
+			issues = append(issues, validateSchemaNode(path, childPath, value, property)...)
+		}
+	case yaml.SequenceNode:
+		if schema.Items != nil {
+			for i, child := range node.Content {
+				childPath := append(append([]string{}, keyPath...), strconv.Itoa(i))
+				issues = append(issues, validateSchemaNode(path, childPath, child, schema.Items)...)
+			}
+		}
+	case yaml.ScalarNode:
+		if message := checkScalarValue(node.Value, schema); message != "" {
+			issues = append(issues, issue(node.Line, "%s", message)...)
+		}
+	}
+	return issues
+}
+
+// schemaNodeTypeMatches 判断 YAML 节点是否符合 Schema 的类型
+func schemaNodeTypeMatches(node *yaml.Node, schema *JSONSchema) bool {
+	switch schema.Type {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	}
+	return true
+}
+
+// describeYAMLNode 描述 YAML 节点的实际类型
+func describeYAMLNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "映射"
+	case yaml.SequenceNode:
+		return "列表"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+// checkScalarValue 检查标量值是否满足 Schema 的取值约束，返回空字符串表示通过
+func checkScalarValue(value string, schema *JSONSchema) string {
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if value == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("取值 %q 无效（可选 %s）", value, strings.Join(schema.Enum, "、"))
+	}
+
+	if schema.Minimum != nil || schema.Maximum != nil || schema.ExclusiveMaximum != nil {
+		number, err := strconv.ParseFloat(value, 64)
+		tooLarge := (schema.Maximum != nil && number > *schema.Maximum) || (schema.ExclusiveMaximum != nil && number >= *schema.ExclusiveMaximum)
+		if err != nil || (schema.Minimum != nil && number < *schema.Minimum) || tooLarge {
+			if schema.ExclusiveMaximum != nil {
+				bound := formatSchemaBound(schema.ExclusiveMaximum, schema.Type)
+				return fmt.Sprintf("%s 必须在 %s 到 %s 之间（不含 %s）", value, formatSchemaBound(schema.Minimum, schema.Type), bound, bound)
+			}
+			return fmt.Sprintf("%s 必须在 %s 到 %s 之间", value, formatSchemaBound(schema.Minimum, schema.Type), formatSchemaBound(schema.Maximum, schema.Type))
+		}
+	}
+
+	if schema.Pattern != "" {
+		if matched, err := regexp.MatchString(schema.Pattern, value); err != nil || !matched {
+			if schema.ErrorMessage != "" {
+				if strings.TrimSpace(value) == "" {
+					return schema.ErrorMessage
+				}
+				return fmt.Sprintf("%q %s", value, schema.ErrorMessage)
+			}
+			return fmt.Sprintf("%q 不匹配 %s", value, schema.Pattern)
+		}
+	}
+
+	if schema.Format == "glob" && !validCategoryPattern(value) {
+		return fmt.Sprintf("模式 %q 不是合法的 glob", value)
+	}
+	return ""
+}
+
+// formatSchemaBound 格式化取值范围的边界，number 类型保留小数点（1.0 而不是 1）
+func formatSchemaBound(bound *float64, schemaType string) string {
+	if bound == nil {
+		return "∞"
+	}
+	text := strconv.FormatFloat(*bound, 'f', -1, 64)
+	if schemaType == "number" && !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return text
+}
+
+// ValidateConfigLayers 校验所有配置层文件
+func ValidateConfigLayers(layers []ConfigLayer) []ValidationIssue {
+	var issues []ValidationIssue
+	for _, layer := range layers {
+		if layer.Scope == ScopeProfile {
+			issues = append(issues, validateProfileLayer(layer)...)
+			continue
+		}
+		schema := configFileSchema(layer.Path)
+		if schema == nil {
+			issues = append(issues, ValidationIssue{File: layer.Path, Message: "无法识别的配置文件"})
+			continue
+		}
+		issues = append(issues, validateConfigData(layer.Path, layer.Data, schema)...)
+	}
+	return issues
+}
+
+// validateProfileLayer 校验配置档案中的文件类型管理器配置段
+//
+// 档案内容嵌套在 config.yaml 中，重新编码后的行号没有意义，因此不报告行号。
+func validateProfileLayer(layer ConfigLayer) []ValidationIssue {
+	data, err := yaml.Marshal(layer.Tree)
+	if err != nil {
+		return []ValidationIssue{{File: layer.Path, Message: fmt.Sprintf("编码失败: %v", err)}}
+	}
+	issues := validateConfigData(layer.Path, data, configFileSchema(RepoConfigFileName))
+	for i := range issues {
+		issues[i].Line = 0
+	}
+	return issues
+}
+
+// validateMergedTree 校验合并后的配置是否完整（各字段可能分散在不同配置层中）
+func validateMergedTree(ftm *FileTypeManager) []ValidationIssue {
+	const merged = "<合并后的配置>"
+	var issues []ValidationIssue
+
+	for _, categoryName := range sortedKeys(ftm.fileTypes.FileTypes) {
+		for _, typeName := range sortedKeys(ftm.fileTypes.FileTypes[categoryName]) {
+			item := ftm.fileTypes.FileTypes[categoryName][typeName]
+			key := fmt.Sprintf("file_types.%s.%s", categoryName, typeName)
+			if len(item.Extensions) == 0 && len(item.Filenames) == 0 && len(item.Shebangs) == 0 {
+				issues = append(issues, ValidationIssue{File: merged, Message: key + ": 缺少 extensions、filenames 或 shebangs"})
+			}
+			if item.Description == "" {
+				issues = append(issues, ValidationIssue{File: merged, Message: key + ": 缺少 description"})
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(ftm.categories.DirectoryPatterns) {
+		pattern := ftm.categories.DirectoryPatterns[name]
+		key := "categories.directory_patterns." + name
+		if len(pattern.Patterns) == 0 {
+			issues = append(issues, ValidationIssue{File: merged, Message: key + ": 缺少 patterns"})
+		}
+		if pattern.Description == "" {
+			issues = append(issues, ValidationIssue{File: merged, Message: key + ": 缺少 description"})
+		}
+	}
+
+	if ftm.categories.Default == "" {
+		issues = append(issues, ValidationIssue{File: merged, Message: "categories.default: 缺少默认分类"})
+	}
+	return issues
+}
+
+// sortedKeys 返回映射的有序键列表
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundledConfigsAreValid(t *testing.T) {
+	for _, name := range layerFiles {
+		path := filepath.Join("..", "..", "configs", name)
+		if issues := ValidateConfigFile(path); len(issues) > 0 {
+			t.Errorf("内置配置 %s 校验失败: %v", name, issues)
+		}
+	}
+}
+
+func TestValidateConfigFileReportsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RepoConfigFileName)
+	content := `categories:
+  directory_patterns:
+    test:
+      pattern: ["x"]
+file_types:
+  web:
+    ts:
+      extensions: ["ts"]
+compress:
+  rate: 3
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	issues := ValidateConfigFile(path)
+	expected := map[int]string{
+		4:  "pattern",
+		8:  "应以 . 开头",
+		10: "0.1 到 1.0",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("期望 %d 个问题，实际为 %v", len(expected), issues)
+	}
+	for _, issue := range issues {
+		want, ok := expected[issue.Line]
+		if !ok || !strings.Contains(issue.Message, want) {
+			t.Errorf("第 %d 行的问题不符合预期: %s", issue.Line, issue.Message)
+		}
+	}
+}
+
+func TestValidateConfigFileSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte("categories:\n  default: [\n"), 0644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	issues := ValidateConfigFile(path)
+	if len(issues) != 1 || issues[0].Line == 0 {
+		t.Errorf("期望一个带行号的语法错误，实际为 %v", issues)
+	}
+}
+
+func TestValidateMergedTree(t *testing.T) {
+	ftm, err := newFileTypeManagerFromTree(map[string]interface{}{
+		"categories": map[string]interface{}{
+			"directory_patterns": map[string]interface{}{
+				"docs": map[string]interface{}{"patterns": []interface{}{"docs"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建文件类型管理器失败: %v", err)
+	}
+
+	issues := validateMergedTree(ftm)
+	if len(issues) != 2 {
+		t.Errorf("期望缺少 description 和 default 两个问题，实际为 %v", issues)
+	}
+}
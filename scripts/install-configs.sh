@@ -80,9 +80,11 @@ show_install_info() {
     echo "  项目配置: $CONFIGS_DIR"
     echo "  用户配置: $USER_CONFIG_DIR"
     echo ""
-    echo -e "${YELLOW}配置文件优先级:${NC}"
-    echo "  1. 项目目录 (./configs/)"
+    echo -e "${YELLOW}配置文件优先级 (从高到低):${NC}"
+    echo "  1. 仓库及子目录中的 .cyber-zen.yaml"
     echo "  2. 用户目录 (~/.cyber-zen/configs/)"
+    echo "  3. 程序同目录的 configs/"
+    echo "  4. 编译进程序的默认配置"
     echo ""
     echo -e "${GREEN}安装完成！程序会自动按优先级查找配置文件。${NC}"
 }
@@ -185,8 +185,8 @@ install_configs() {
     
     if [ $success_count -eq ${#config_files[@]} ]; then
         print_success "✓ 所有配置文件安装完成: $user_config_dir"
-        print_info "配置文件优先级:"
-        print_info "  1. 项目目录 (./configs/)"
+        print_info "配置文件优先级 (从高到低):"
+        print_info "  1. 仓库及子目录中的 .cyber-zen.yaml"
         print_info "  2. 用户目录 ($user_config_dir)"
     else
         print_warning "⚠ 部分配置文件安装失败，但程序仍可正常使用"